@@ -3,6 +3,7 @@ package context
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/taiyang-li/automi/api"
 )
@@ -12,6 +13,10 @@ type ctxKey int
 var (
 	logFuncKey ctxKey = 1
 	errFuncKey ctxKey = 2
+	poolKey    ctxKey = 3
+	nameKey    ctxKey = 4
+	traceKey   ctxKey = 5
+	seedKey    ctxKey = 6
 )
 
 // WithLogFunc sets the function to handle logging from runtime components
@@ -21,7 +26,7 @@ func WithLogFunc(ctx context.Context, logFunc api.LogFunc) context.Context {
 
 // GetLogFunc returns the log function stored in the context.
 func GetLogFunc(ctx context.Context) func(interface{}) {
-	fn, ok := ctx.Value(logFuncKey).(func(interface{}))
+	fn, ok := ctx.Value(logFuncKey).(api.LogFunc)
 	if !ok {
 		return nil
 	}
@@ -79,3 +84,64 @@ func Err(fn api.ErrorFunc, err api.StreamError) {
 		fn(err)
 	}
 }
+
+// WithPool sets a resource pool that operator functions can use
+// to reuse expensive per-item objects.
+func WithPool(ctx context.Context, pool *sync.Pool) context.Context {
+	return context.WithValue(ctx, poolKey, pool)
+}
+
+// GetPool returns the resource pool stored in the context or
+// nil if no pool was set.
+func GetPool(ctx context.Context) *sync.Pool {
+	pool, ok := ctx.Value(poolKey).(*sync.Pool)
+	if !ok {
+		return nil
+	}
+	return pool
+}
+
+// WithStreamName sets the name of the stream running the components
+func WithStreamName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nameKey, name)
+}
+
+// GetStreamName returns the stream name stored in the context or
+// an empty string if the stream is not named.
+func GetStreamName(ctx context.Context) string {
+	name, ok := ctx.Value(nameKey).(string)
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// WithTraceID sets the correlation ID of the item being processed
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceKey, id)
+}
+
+// GetTraceID returns the correlation ID of the item being processed
+// or an empty string if the item is not traced.
+func GetTraceID(ctx context.Context) string {
+	id, ok := ctx.Value(traceKey).(string)
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+// WithHashSeed sets the seed used to hash keys by partitioning components
+func WithHashSeed(ctx context.Context, seed uint64) context.Context {
+	return context.WithValue(ctx, seedKey, seed)
+}
+
+// GetHashSeed returns the hash seed stored in the context or 0,
+// the default seed, if none was set.
+func GetHashSeed(ctx context.Context) uint64 {
+	seed, ok := ctx.Value(seedKey).(uint64)
+	if !ok {
+		return 0
+	}
+	return seed
+}
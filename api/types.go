@@ -59,8 +59,9 @@ type ErrorFunc func(StreamError)
 
 // StreamError is used to signal runtime stream error
 type StreamError struct {
-	err  string      // Error message
-	item *StreamItem // Item that caused error
+	err   string      // Error message
+	item  *StreamItem // Item that caused error
+	cause error       // Underlying error, if any
 }
 
 func (e StreamError) Error() string {
@@ -77,11 +78,31 @@ func Error(msg string) StreamError {
 	return StreamError{err: msg}
 }
 
+// Cause returns the underlying error wrapped by the StreamError, if any
+func (e StreamError) Cause() error {
+	return e.cause
+}
+
+// Unwrap returns the underlying error so that the StreamError can be
+// inspected with errors.Is and errors.As
+func (e StreamError) Unwrap() error {
+	return e.cause
+}
+
 // ErrorWithItem returns a StreamError with provided StreamItem
 func ErrorWithItem(msg string, item *StreamItem) StreamError {
 	return StreamError{err: msg, item: item}
 }
 
+// WrapError returns a StreamError that wraps err, as its cause, with
+// the provided StreamItem (which may be nil)
+func WrapError(err error, item *StreamItem) StreamError {
+	if err == nil {
+		return StreamError{item: item}
+	}
+	return StreamError{err: err.Error(), item: item, cause: err}
+}
+
 // PanicStreamError signals that the stream should panic immediately
 type PanicStreamError StreamError
 
@@ -89,13 +110,26 @@ func (e PanicStreamError) Error() string {
 	return e.err
 }
 
+// Item returns the StreamItem associated with the error
+func (e PanicStreamError) Item() *StreamItem {
+	return e.item
+}
+
 // PanickingError returns a PanicStreamError
 func PanickingError(msg string) PanicStreamError {
 	return PanicStreamError(Error(msg))
 }
 
+// PanickingErrorWithItem returns a PanicStreamError with provided StreamItem
+func PanickingErrorWithItem(msg string, item *StreamItem) PanicStreamError {
+	return PanicStreamError(ErrorWithItem(msg, item))
+}
+
 // CancelStreamError signals that all stream activities should stop
-// and the streaming should gracefully end
+// and the streaming should gracefully end.  When returned by an operator
+// function, the error is reported to the error function then the stream
+// context is cancelled, which stops the emitter and all operators.  By
+// contrast, a StreamError (see Error) is reported and the stream continues.
 type CancelStreamError StreamError
 
 func (e CancelStreamError) Error() string {
@@ -116,3 +150,11 @@ type StreamItem struct {
 	MetaData map[string]string // user-provided stream metadat
 	Context  context.Context   // stream context
 }
+
+// TraceIDKey is the StreamItem.MetaData key used to carry
+// the correlation ID of items traced through a stream.
+const TraceIDKey = "automi.trace-id"
+
+// OffsetKey is the StreamItem.MetaData key used to mark items that carry,
+// in StreamItem.Index, the position of their source item in the stream.
+const OffsetKey = "automi.offset"
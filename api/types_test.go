@@ -0,0 +1,31 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestStreamError_WrapError(t *testing.T) {
+	item := &StreamItem{Item: "key"}
+	err := WrapError(fmt.Errorf("lookup: %w", errNotFound), item)
+
+	if err.Error() != "lookup: not found" {
+		t.Fatal("unexpected error message:", err.Error())
+	}
+	if err.Item() != item {
+		t.Fatal("expecting item to be attached")
+	}
+	if !errors.Is(err, errNotFound) {
+		t.Fatal("expecting wrapped error to match sentinel")
+	}
+	var target StreamError
+	if !errors.As(error(err), &target) || target.Cause() == nil {
+		t.Fatal("expecting StreamError with cause")
+	}
+	if Error("failed").Cause() != nil {
+		t.Fatal("expecting no cause for plain StreamError")
+	}
+}
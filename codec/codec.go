@@ -0,0 +1,65 @@
+// Package codec provides serialization codecs that can be used by
+// operators, emitters and collectors to encode and decode streamed items.
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec marshals values to bytes and unmarshals bytes into values
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct {
+	indent string
+}
+
+// JSON returns a Codec that uses package encoding/json
+func JSON() Codec {
+	return jsonCodec{}
+}
+
+// PrettyJSON returns a JSON Codec that indents marshalled values
+// with the specified indent string (i.e. "  ").
+func PrettyJSON(indent string) Codec {
+	return jsonCodec{indent: indent}
+}
+
+// Marshal returns the JSON encoding of v
+func (c jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	if c.indent != "" {
+		return json.MarshalIndent(v, "", c.indent)
+	}
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v
+func (c jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+// Gob returns a Codec that uses package encoding/gob.  Concrete types
+// sent as interface values must be registered with gob.Register.
+func Gob() Codec {
+	return gobCodec{}
+}
+
+// Marshal returns the gob encoding of v
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,49 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	type event struct {
+		Name  string
+		Count int
+	}
+
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{"json", JSON()},
+		{"pretty json", PrettyJSON("  ")},
+		{"gob", Gob()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := event{Name: "click", Count: 3}
+			data, err := test.codec.Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out event
+			if err := test.codec.Unmarshal(data, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Fatalf("expecting %v, got %v", in, out)
+			}
+		})
+	}
+}
+
+func TestCodec_PrettyJSON(t *testing.T) {
+	data, err := PrettyJSON("  ").Marshal(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\n  \"a\": 1\n}" {
+		t.Fatal("unexpected pretty JSON:", string(data))
+	}
+}
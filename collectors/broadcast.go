@@ -0,0 +1,162 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// BroadcastMode determines how a BroadcastCollector writes
+// items to its sinks.
+type BroadcastMode int
+
+const (
+	// BroadcastSequential writes each item to the sinks one after
+	// the other (default).
+	BroadcastSequential BroadcastMode = iota
+
+	// BroadcastConcurrent writes each item to all sinks concurrently,
+	// then waits for all writes to complete before the next item.
+	BroadcastConcurrent
+
+	// BroadcastBuffered decouples the sinks with a buffer of items for
+	// each sink, so a slow sink only delays the others when its buffer
+	// is full.
+	BroadcastBuffered
+)
+
+// BroadcastCollector is a collector that writes each collected
+// item to all of its sinks.
+type BroadcastCollector struct {
+	input <-chan interface{}
+	logf  api.LogFunc
+	sinks []api.Sink
+	mode  BroadcastMode
+}
+
+// Broadcast creates a new value *BroadcastCollector that writes each item
+// to all the provided sinks.  The sinks are opened and closed with the
+// collector.  A sink that stops early (i.e. it failed) no longer receives
+// items and does not block the other sinks.  The errors returned by the
+// sinks are aggregated in the error returned by the collector.
+func Broadcast(sinks ...api.Sink) *BroadcastCollector {
+	return &BroadcastCollector{sinks: sinks}
+}
+
+// Mode sets how items are written to the sinks
+func (c *BroadcastCollector) Mode(mode BroadcastMode) *BroadcastCollector {
+	c.mode = mode
+	return c
+}
+
+// SetInput sets the channel input
+func (c *BroadcastCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *BroadcastCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(c.logf, "Opening broadcast collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("Broadcast collector missing input") }()
+		return result
+	}
+
+	bufferSize := 0
+	if c.mode == BroadcastBuffered {
+		bufferSize = 1024
+	}
+
+	// open sinks, each sink is marked as stopped when it completes
+	inputs := make([]chan interface{}, len(c.sinks))
+	stopped := make([]chan struct{}, len(c.sinks))
+	errs := make([]error, len(c.sinks))
+	var sinksWg sync.WaitGroup
+	for i, snk := range c.sinks {
+		inputs[i] = make(chan interface{}, bufferSize)
+		stopped[i] = make(chan struct{})
+		snk.SetInput(inputs[i])
+		done := snk.Open(ctx)
+		sinksWg.Add(1)
+		go func(i int) {
+			defer sinksWg.Done()
+			errs[i] = <-done
+			close(stopped[i])
+		}(i)
+	}
+
+	send := func(i int, item interface{}) {
+		select {
+		case inputs[i] <- item:
+		case <-stopped[i]:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing broadcast collector")
+			close(result)
+		}()
+
+	loop:
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					break loop
+				}
+				if c.mode == BroadcastConcurrent {
+					var wg sync.WaitGroup
+					for i := range inputs {
+						wg.Add(1)
+						go func(i int) {
+							defer wg.Done()
+							send(i, item)
+						}(i)
+					}
+					wg.Wait()
+					continue
+				}
+				for i := range inputs {
+					send(i, item)
+				}
+			case <-ctx.Done():
+				break loop
+			}
+		}
+
+		for _, input := range inputs {
+			close(input)
+		}
+		sinksWg.Wait()
+		if err := joinErrors(errs); err != nil {
+			result <- err
+		}
+	}()
+
+	return result
+}
+
+// joinErrors aggregates the non-nil errors into a single error
+func joinErrors(errs []error) error {
+	var msgs []string
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d sink(s) failed: %s", len(msgs), strings.Join(msgs, "; "))
+}
@@ -0,0 +1,79 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollector_Broadcast(t *testing.T) {
+	modes := []BroadcastMode{BroadcastSequential, BroadcastConcurrent, BroadcastBuffered}
+	for _, mode := range modes {
+		in := make(chan interface{})
+		go func() {
+			for i := 1; i <= 3; i++ {
+				in <- i
+			}
+			close(in)
+		}()
+
+		snk1, snk2 := Slice(), Slice()
+		c := Broadcast(snk1, snk2).Mode(mode)
+		c.SetInput(in)
+
+		select {
+		case err := <-c.Open(context.TODO()):
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+
+		expected := []interface{}{1, 2, 3}
+		if !reflect.DeepEqual(snk1.Get(), expected) || !reflect.DeepEqual(snk2.Get(), expected) {
+			t.Fatalf("mode %d: unexpected result %v %v", mode, snk1.Get(), snk2.Get())
+		}
+	}
+}
+
+func TestCollector_Broadcast_FailingSinks(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 1; i <= 3; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	// a func collector without a function stops immediately with an error
+	good := Slice()
+	c := Broadcast(Func(nil), good, Func(nil)).Mode(BroadcastConcurrent)
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.TODO()):
+		if err == nil || !strings.HasPrefix(err.Error(), "2 sink(s) failed") {
+			t.Fatal("expecting aggregated errors, got", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("failing sinks blocked the broadcast")
+	}
+
+	if len(good.Get()) != 3 {
+		t.Fatal("unexpected result:", good.Get())
+	}
+}
+
+func TestCollector_Broadcast_JoinErrors(t *testing.T) {
+	if joinErrors([]error{nil, nil}) != nil {
+		t.Fatal("expecting nil error")
+	}
+	err := joinErrors([]error{errors.New("a"), nil, errors.New("b")})
+	if err == nil || err.Error() != "2 sink(s) failed: a; b" {
+		t.Fatal("unexpected error:", err)
+	}
+}
@@ -0,0 +1,136 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// ByTypeCollector is a collector that routes each collected item to
+// the sink registered for the item's dynamic type.
+type ByTypeCollector struct {
+	input        <-chan interface{}
+	logf         api.LogFunc
+	errf         api.ErrorFunc
+	routes       map[reflect.Type]chan interface{}
+	defaultRoute chan interface{}
+	sinks        []api.Sink
+	inputs       []chan interface{} // input of each sink
+}
+
+// ByType creates a new value *ByTypeCollector that routes items to the sink
+// registered for their type in routes, or to defaultSink for unregistered
+// types.  When defaultSink is nil, items of unregistered types are reported
+// to the error function.  The sinks are opened and closed with the collector;
+// a sink registered for several types is opened once and receives the items
+// of all its types.
+func ByType(routes map[reflect.Type]api.Sink, defaultSink api.Sink) *ByTypeCollector {
+	c := &ByTypeCollector{routes: make(map[reflect.Type]chan interface{})}
+	for typ, snk := range routes {
+		c.routes[typ] = c.addSink(snk)
+	}
+	if defaultSink != nil {
+		c.defaultRoute = c.addSink(defaultSink)
+	}
+	return c
+}
+
+// addSink sets up the input of snk and returns it, sinks already
+// added share their input
+func (c *ByTypeCollector) addSink(snk api.Sink) chan interface{} {
+	if reflect.TypeOf(snk).Comparable() {
+		for i, added := range c.sinks {
+			if added == snk {
+				return c.inputs[i]
+			}
+		}
+	}
+	input := make(chan interface{}, 1024)
+	snk.SetInput(input)
+	c.sinks = append(c.sinks, snk)
+	c.inputs = append(c.inputs, input)
+	return input
+}
+
+// SetInput sets the channel input
+func (c *ByTypeCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *ByTypeCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening by-type collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("ByType collector missing input") }()
+		return result
+	}
+
+	dones := make([]<-chan error, len(c.sinks))
+	for i, snk := range c.sinks {
+		dones[i] = snk.Open(ctx)
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing by-type collector")
+			close(result)
+		}()
+
+		c.route(ctx)
+
+		// close sink inputs then wait for them
+		for _, input := range c.inputs {
+			close(input)
+		}
+		var err error
+		for _, done := range dones {
+			if snkErr := <-done; err == nil {
+				err = snkErr
+			}
+		}
+		if err != nil {
+			result <- err
+		}
+	}()
+
+	return result
+}
+
+// route sends incoming items to the sinks until the input is closed
+func (c *ByTypeCollector) route(ctx context.Context) {
+	for {
+		select {
+		case item, opened := <-c.input:
+			if !opened {
+				return
+			}
+			output, ok := c.routes[reflect.TypeOf(item)]
+			if !ok {
+				output = c.defaultRoute
+			}
+			if output == nil {
+				err := fmt.Errorf("ByType collector has no sink for item type %T", item)
+				util.Logfn(c.logf, err)
+				autoctx.Err(c.errf, api.ErrorWithItem(err.Error(), &api.StreamItem{Item: item}))
+				continue
+			}
+			select {
+			case output <- item:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestCollector_ByType(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- "a"
+		in <- 2.5
+		in <- 3
+		close(in)
+	}()
+
+	ints, strs, others := Slice(), Slice(), Slice()
+	c := ByType(map[reflect.Type]api.Sink{
+		reflect.TypeOf(0):  ints,
+		reflect.TypeOf(""): strs,
+	}, others)
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if !reflect.DeepEqual(ints.Get(), []interface{}{1, 3}) {
+		t.Fatal("unexpected ints:", ints.Get())
+	}
+	if !reflect.DeepEqual(strs.Get(), []interface{}{"a"}) {
+		t.Fatal("unexpected strings:", strs.Get())
+	}
+	if !reflect.DeepEqual(others.Get(), []interface{}{2.5}) {
+		t.Fatal("unexpected others:", others.Get())
+	}
+}
+
+func TestCollector_ByType_SharedSink(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- int64(2)
+		in <- "a"
+		close(in)
+	}()
+
+	snk := Slice()
+	c := ByType(map[reflect.Type]api.Sink{
+		reflect.TypeOf(0):        snk,
+		reflect.TypeOf(int64(0)): snk,
+	}, snk)
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{1, int64(2), "a"}) {
+		t.Fatal("unexpected items:", snk.Get())
+	}
+}
+
+func TestCollector_ByType_NoDefault(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- "a"
+		close(in)
+	}()
+
+	errs := 0
+	ctx := autoctx.WithErrorFunc(context.Background(), func(api.StreamError) {
+		errs++
+	})
+	ints := Slice()
+	c := ByType(map[reflect.Type]api.Sink{reflect.TypeOf(0): ints}, nil)
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(ints.Get()) != 1 || errs != 1 {
+		t.Fatal("unexpected result:", ints.Get(), "errors:", errs)
+	}
+}
@@ -0,0 +1,93 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// ChanCollector is a collector that sends collected
+// items onto a typed channel of type chan T or chan<- T.
+type ChanCollector struct {
+	input <-chan interface{}
+	logf  api.LogFunc
+	errf  api.ErrorFunc
+	ch    interface{}
+}
+
+// Chan creates a new value *ChanCollector that sends collected items
+// on channel ch.  Items that are not assignable to the channel element type T
+// are reported to the error function.  The channel is closed when the
+// collector is done.
+func Chan(ch interface{}) *ChanCollector {
+	return &ChanCollector{ch: ch}
+}
+
+// SetInput sets the channel input
+func (c *ChanCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *ChanCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening chan collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("Chan collector missing input") }()
+		return result
+	}
+
+	if !util.IsSendChan(c.ch) || reflect.ValueOf(c.ch).IsNil() {
+		err := fmt.Errorf("Chan collector expects a non-nil send channel, got %T", c.ch)
+		util.Logfn(c.logf, err)
+		go func() { result <- err }()
+		return result
+	}
+
+	chVal := reflect.ValueOf(c.ch)
+	elemType := chVal.Type().Elem()
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing chan collector")
+			chVal.Close()
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				itemVal := reflect.ValueOf(item)
+				if !itemVal.IsValid() || !itemVal.Type().AssignableTo(elemType) {
+					err := fmt.Errorf("Chan collector item type %T not assignable to %s", item, elemType)
+					util.Logfn(c.logf, err)
+					autoctx.Err(c.errf, api.ErrorWithItem(err.Error(), &api.StreamItem{Item: item}))
+					continue
+				}
+				chosen, _, _ := reflect.Select([]reflect.SelectCase{
+					{Dir: reflect.SelectSend, Chan: chVal, Send: itemVal},
+					{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+				})
+				if chosen == 1 {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
@@ -0,0 +1,74 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestCollector_Chan(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- "two"
+		in <- 3
+		close(in)
+	}()
+
+	errs := 0
+	ctx := autoctx.WithErrorFunc(context.Background(), func(api.StreamError) {
+		errs++
+	})
+
+	out := make(chan int, 3)
+	c := Chan((chan<- int)(out))
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	var result []int
+	for i := range out {
+		result = append(result, i)
+	}
+	if len(result) != 2 || result[0] != 1 || result[1] != 3 {
+		t.Fatal("unexpected result:", result)
+	}
+	if errs != 1 {
+		t.Fatal("expecting 1 type mismatch error, got", errs)
+	}
+}
+
+func TestCollector_ChanErr(t *testing.T) {
+	var nilCh chan int
+	tests := []struct {
+		name string
+		ch   interface{}
+	}{
+		{"receive-only channel", make(<-chan int)},
+		{"nil channel", nilCh},
+	}
+
+	for _, test := range tests {
+		c := Chan(test.ch)
+		c.SetInput(make(chan interface{}))
+
+		select {
+		case err := <-c.Open(context.TODO()):
+			if err == nil {
+				t.Fatalf("Expecting error for %s", test.name)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// CountCollector is a collector that counts incoming items by key
+// without retaining the items themselves.
+type CountCollector struct {
+	keyFn  func(interface{}) interface{}
+	counts map[interface{}]int64
+	input  <-chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// CountByKey creates a *CountCollector that uses keyFn to compute
+// the key of each incoming item.  If keyFn is nil, the item itself
+// is used as the key.  Keys must be comparable values, items with
+// uncomparable keys are reported to the error function.
+func CountByKey(keyFn func(interface{}) interface{}) *CountCollector {
+	return &CountCollector{
+		keyFn:  keyFn,
+		counts: make(map[interface{}]int64),
+	}
+}
+
+// SetInput sets the channel input
+func (c *CountCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Get returns the count for each collected key
+func (c *CountCollector) Get() map[interface{}]int64 {
+	return c.counts
+}
+
+// Open is the starting point that starts the collector
+func (c *CountCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening count collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("Count collector missing input") }()
+		return result
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing count collector")
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				key := item
+				if c.keyFn != nil {
+					key = c.keyFn(item)
+				}
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					msg := fmt.Sprintf("count collector: key of type %T is not comparable", key)
+					util.Logfn(c.logf, msg)
+					autoctx.Err(c.errf, api.ErrorWithItem(msg, &api.StreamItem{Item: item}))
+					continue
+				}
+				c.counts[key]++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
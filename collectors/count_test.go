@@ -0,0 +1,105 @@
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestCollector_CountByKey(t *testing.T) {
+	cc := CountByKey(func(item interface{}) interface{} {
+		return strings.ToLower(item.(string))
+	})
+	in := make(chan interface{})
+	go func() {
+		for _, word := range []string{"A", "b", "a", "C", "B", "a"} {
+			in <- word
+		}
+		close(in)
+	}()
+	cc.SetInput(in)
+
+	select {
+	case err := <-cc.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := cc.Get()
+		if len(counts) != 3 {
+			t.Fatal("unexpected key count ", len(counts))
+		}
+		if counts["a"] != 3 || counts["b"] != 2 || counts["c"] != 1 {
+			t.Fatal("unexpected counts ", counts)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestCollector_CountByKey_NilKeyFunc(t *testing.T) {
+	cc := CountByKey(nil)
+	in := make(chan interface{})
+	go func() {
+		for _, val := range []int{1, 2, 1} {
+			in <- val
+		}
+		close(in)
+	}()
+	cc.SetInput(in)
+
+	select {
+	case err := <-cc.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cc.Get()[1] != 2 || cc.Get()[2] != 1 {
+			t.Fatal("unexpected counts ", cc.Get())
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestCollector_CountByKey_NotComparable(t *testing.T) {
+	var m sync.Mutex
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.TODO(), func(err api.StreamError) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+
+	cc := CountByKey(nil)
+	in := make(chan interface{})
+	go func() {
+		in <- []int{1}
+		in <- "a"
+		in <- []int{2}
+		close(in)
+	}()
+	cc.SetInput(in)
+
+	select {
+	case err := <-cc.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if !reflect.DeepEqual(cc.Get(), map[interface{}]int64{"a": 1}) {
+		t.Fatal("unexpected counts ", cc.Get())
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 2 || errs[0].Item() == nil {
+		t.Fatal("expecting 2 errors with items, got ", errs)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 
 	"github.com/taiyang-li/automi/api"
 	autoctx "github.com/taiyang-li/automi/api/context"
@@ -15,11 +16,14 @@ import (
 
 // CsvCollector represents a node that can collect items streamed as
 // type []string and write them as comma-separated values to the specified
-// io.Writer or file.
+// io.Writer or file.  Items that are structs (or pointers to structs) are
+// written with one column per exported field.  Unless headers are provided,
+// the field names of the first struct item are written as the header row.
 type CsvCollector struct {
 	filepath  string   // path for the file
 	delimChar rune     // delimiter character
 	headers   []string // optional csv headers
+	headerOut bool     // indicates header row was written
 
 	snkParam  interface{}
 	file      *os.File
@@ -83,6 +87,7 @@ func (c *CsvCollector) init(ctx context.Context) error {
 		if err := c.csvWriter.Write(c.headers); err != nil {
 			return err
 		}
+		c.headerOut = true
 	}
 	return nil
 }
@@ -126,9 +131,12 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 					return
 				}
 				data, ok := item.([]string)
+				if !ok {
+					data, ok = c.structRecord(item)
+				}
 
 				if !ok { // bad situation, fail fast
-					msg := fmt.Sprintf("expecting []string, got unexpected type %T", data)
+					msg := fmt.Sprintf("expecting []string or struct, got unexpected type %T", item)
 					util.Logfn(c.logf, msg)
 					autoctx.Err(c.errf, api.Error(msg))
 					panic(msg)
@@ -159,6 +167,40 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 	return result
 }
 
+// structRecord converts the exported fields of a struct item into a record.
+// The header row is written, from the field names, before the first record
+// if it was not written already.
+func (c *CsvCollector) structRecord(item interface{}) ([]string, bool) {
+	val := reflect.ValueOf(item)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	var names, record []string
+	valType := val.Type()
+	for i := 0; i < valType.NumField(); i++ {
+		field := valType.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		names = append(names, field.Name)
+		record = append(record, fmt.Sprint(val.Field(i).Interface()))
+	}
+
+	if !c.headerOut {
+		if err := c.csvWriter.Write(names); err != nil {
+			perr := fmt.Errorf("Unable to write header to file: %s ", err)
+			util.Logfn(c.logf, perr)
+			autoctx.Err(c.errf, api.Error(perr.Error()))
+		}
+		c.headerOut = true
+	}
+	return record, true
+}
+
 func (c *CsvCollector) setupSink() error {
 	if c.snkParam == nil {
 		return errors.New("missing CSV sink")
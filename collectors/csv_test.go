@@ -3,6 +3,7 @@ package collectors
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -54,6 +55,62 @@ func TestCsvCollector_IO(t *testing.T) {
 	}
 }
 
+func TestCsvCollector_Structs(t *testing.T) {
+	type person struct {
+		First string
+		Last  string
+		Age   int
+		note  string
+	}
+
+	in := make(chan interface{})
+	go func() {
+		in <- person{"Christophe", "Petion", 48, "x"}
+		in <- &person{"Toussaint", "Guerrier", 63, "y"}
+		close(in)
+	}()
+	data := bytes.NewBufferString("")
+	csv := CSV(data)
+	csv.SetInput(in)
+
+	select {
+	case err := <-csv.Open(context.Background()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+
+	expected := "First,Last,Age\nChristophe,Petion,48\nToussaint,Guerrier,63"
+	actual := strings.TrimSpace(data.String())
+	if actual != expected {
+		t.Fatal("collector did not get expected data, got: ", actual)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestCsvCollector_FlushError(t *testing.T) {
+	in := make(chan interface{})
+	close(in)
+	csv := CSV(failingWriter{}).Headers([]string{"a", "b"})
+	csv.SetInput(in)
+
+	select {
+	case err := <-csv.Open(context.Background()):
+		if err == nil {
+			t.Fatal("expecting flush error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+}
+
 func TestCsvCollector_File(t *testing.T) {
 	in := make(chan interface{})
 	go func() {
@@ -0,0 +1,114 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// deadLetter is the JSON record written for each failed item
+type deadLetter struct {
+	Error string      `json:"error,omitempty"`
+	Item  interface{} `json:"item"`
+}
+
+// DeadLetterCollector is a collector that writes failed items, received as
+// api.StreamError values, to an io.Writer as JSON lines so they can be
+// inspected or replayed later.
+type DeadLetterCollector struct {
+	writer io.Writer
+	input  <-chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// DeadLetter creates a *DeadLetterCollector that writes a JSON line, as in
+//
+//   {"error":"invalid amount","item":{"id":7,"amount":-1}}
+//
+// to writer for each incoming api.StreamError, with the error message and its
+// attached item (null if none).  Other incoming values are written as items
+// without an error.  It is meant to be used with Stream.WithDeadLetter, or as
+// the dead-letter sink of WithItemRetry.  Items that fail to marshal are
+// reported to the error function without the item and are skipped.  If writer
+// implements a Flush method (i.e. *bufio.Writer), it is flushed when the
+// collector closes.
+func DeadLetter(writer io.Writer) *DeadLetterCollector {
+	return &DeadLetterCollector{
+		writer: writer,
+	}
+}
+
+// SetInput sets the input channel for the collector node
+func (c *DeadLetterCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open opens the collector and starts writing failed items
+func (c *DeadLetterCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening dead-letter collector")
+	result := make(chan error, 1)
+
+	if c.writer == nil {
+		result <- fmt.Errorf("dead-letter collector missing writer")
+		close(result)
+		return result
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing dead-letter collector")
+			if err := flushWriter(c.writer); err != nil {
+				err = fmt.Errorf("dead-letter collector flush: %s", err)
+				util.Logfn(c.logf, err)
+				result <- err
+			}
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				// errors are reported without item, so they are
+				// not routed back to the dead-letter sink
+				data, err := json.Marshal(newDeadLetter(item))
+				if err != nil {
+					util.Logfn(c.logf, err)
+					autoctx.Err(c.errf, api.WrapError(err, nil))
+					continue
+				}
+				if _, err := c.writer.Write(append(data, '\n')); err != nil {
+					util.Logfn(c.logf, err)
+					autoctx.Err(c.errf, api.WrapError(err, nil))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// newDeadLetter returns the record written for item
+func newDeadLetter(item interface{}) deadLetter {
+	streamErr, ok := item.(api.StreamError)
+	if !ok {
+		return deadLetter{Item: item}
+	}
+	record := deadLetter{Error: streamErr.Error()}
+	if streamItem := streamErr.Item(); streamItem != nil {
+		record.Item = streamItem.Item
+	}
+	return record
+}
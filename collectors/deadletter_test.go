@@ -0,0 +1,39 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+)
+
+func TestCollector_DeadLetter(t *testing.T) {
+	var sink bytes.Buffer
+	c := DeadLetter(&sink)
+	in := make(chan interface{})
+	go func() {
+		in <- api.ErrorWithItem("invalid amount", &api.StreamItem{Item: map[string]int{"amount": -1}})
+		in <- api.Error("no item")
+		in <- "raw"
+		close(in)
+	}()
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.Background()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := "{\"error\":\"invalid amount\",\"item\":{\"amount\":-1}}\n" +
+		"{\"error\":\"no item\",\"item\":null}\n" +
+		"{\"item\":\"raw\"}\n"
+	if sink.String() != expected {
+		t.Fatalf("unexpected output %q", sink.String())
+	}
+}
@@ -0,0 +1,87 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// GroupCollector is a collector that groups incoming items by key,
+// preserving the arrival order of the items within each group.
+type GroupCollector struct {
+	keyFn  func(interface{}) interface{}
+	groups map[interface{}][]interface{}
+	input  <-chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// GroupByKey creates a *GroupCollector that uses keyFn to compute
+// the key of each incoming item.  If keyFn is nil, the item itself
+// is used as the key.  Items with keys that are not comparable values
+// are reported to the error function and are not collected.
+func GroupByKey(keyFn func(interface{}) interface{}) *GroupCollector {
+	return &GroupCollector{
+		keyFn:  keyFn,
+		groups: make(map[interface{}][]interface{}),
+	}
+}
+
+// SetInput sets the channel input
+func (c *GroupCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Get returns the collected items for each key
+func (c *GroupCollector) Get() map[interface{}][]interface{} {
+	return c.groups
+}
+
+// Open is the starting point that starts the collector
+func (c *GroupCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening group collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("Group collector missing input") }()
+		return result
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing group collector")
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				key := item
+				if c.keyFn != nil {
+					key = c.keyFn(item)
+				}
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					msg := fmt.Sprintf("group collector: key of type %T is not comparable", key)
+					util.Logfn(c.logf, msg)
+					autoctx.Err(c.errf, api.ErrorWithItem(msg, &api.StreamItem{Item: item}))
+					continue
+				}
+				c.groups[key] = append(c.groups[key], item)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
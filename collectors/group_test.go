@@ -0,0 +1,82 @@
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestCollector_GroupByKey(t *testing.T) {
+	gc := GroupByKey(func(item interface{}) interface{} {
+		return len(item.(string))
+	})
+	in := make(chan interface{})
+	go func() {
+		for _, word := range []string{"a", "bb", "c", "dd", "eee", "f"} {
+			in <- word
+		}
+		close(in)
+	}()
+	gc.SetInput(in)
+
+	select {
+	case err := <-gc.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := map[interface{}][]interface{}{
+		1: {"a", "c", "f"},
+		2: {"bb", "dd"},
+		3: {"eee"},
+	}
+	if !reflect.DeepEqual(gc.Get(), expected) {
+		t.Fatal("unexpected groups ", gc.Get())
+	}
+}
+
+func TestCollector_GroupByKey_NotComparable(t *testing.T) {
+	var m sync.Mutex
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.TODO(), func(err api.StreamError) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+
+	gc := GroupByKey(nil)
+	in := make(chan interface{})
+	go func() {
+		in <- "a"
+		in <- []int{1}
+		in <- "a"
+		close(in)
+	}()
+	gc.SetInput(in)
+
+	select {
+	case err := <-gc.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if !reflect.DeepEqual(gc.Get(), map[interface{}][]interface{}{"a": {"a", "a"}}) {
+		t.Fatal("unexpected groups ", gc.Get())
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 1 {
+		t.Fatal("expecting 1 error, got ", errs)
+	}
+}
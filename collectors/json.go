@@ -0,0 +1,85 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// JSONCollector is a collector that marshals each item to JSON and writes
+// it to an io.Writer as one JSON value per line (JSON lines or NDJSON).
+type JSONCollector struct {
+	writer io.Writer
+	input  <-chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// JSON creates a *JSONCollector that writes JSON lines to writer.  Items that
+// fail to marshal are reported to the error function, with the item attached,
+// and are skipped.  If writer implements a Flush method (i.e. *bufio.Writer),
+// it is flushed when the collector closes.
+func JSON(writer io.Writer) *JSONCollector {
+	return &JSONCollector{
+		writer: writer,
+	}
+}
+
+// SetInput sets the input channel for the collector node
+func (c *JSONCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open opens the collector and starts writing items
+func (c *JSONCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening JSON collector")
+	result := make(chan error, 1)
+
+	if c.writer == nil {
+		result <- fmt.Errorf("JSON collector missing writer")
+		close(result)
+		return result
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing JSON collector")
+			if err := flushWriter(c.writer); err != nil {
+				err = fmt.Errorf("JSON collector flush: %s", err)
+				util.Logfn(c.logf, err)
+				result <- err
+			}
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				data, err := json.Marshal(item)
+				if err != nil {
+					util.Logfn(c.logf, err)
+					autoctx.Err(c.errf, api.WrapError(err, &api.StreamItem{Item: item}))
+					continue
+				}
+				if _, err := c.writer.Write(append(data, '\n')); err != nil {
+					util.Logfn(c.logf, err)
+					autoctx.Err(c.errf, api.WrapError(err, &api.StreamItem{Item: item}))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
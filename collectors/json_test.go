@@ -0,0 +1,53 @@
+package collectors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestCollector_JSON(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var sink bytes.Buffer
+	buf := bufio.NewWriter(&sink)
+	c := JSON(buf)
+	in := make(chan interface{})
+	go func() {
+		in <- event{ID: 1, Name: "start"}
+		in <- make(chan int) // not marshalable
+		in <- map[string]int{"count": 2}
+		close(in)
+	}()
+	c.SetInput(in)
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+
+	select {
+	case err := <-c.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := "{\"id\":1,\"name\":\"start\"}\n{\"count\":2}\n"
+	if sink.String() != expected {
+		t.Fatalf("unexpected output %q", sink.String())
+	}
+	if len(errs) != 1 || errs[0].Item() == nil {
+		t.Fatal("expecting marshal error with item, got", errs)
+	}
+}
@@ -0,0 +1,124 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// MergeFunc resolves the value to store when a key is collected more
+// than once.  It receives the key, the existing value and the incoming value
+// and returns the value to keep or an error to stop the collection.
+type MergeFunc func(key, existing, incoming interface{}) (interface{}, error)
+
+// LastWins is a MergeFunc that keeps the incoming value for duplicate keys
+func LastWins(key, existing, incoming interface{}) (interface{}, error) {
+	return incoming, nil
+}
+
+// FirstWins is a MergeFunc that keeps the existing value for duplicate keys
+func FirstWins(key, existing, incoming interface{}) (interface{}, error) {
+	return existing, nil
+}
+
+// FailOnDuplicate is a MergeFunc that returns an error for duplicate keys
+func FailOnDuplicate(key, existing, incoming interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("duplicate key %v", key)
+}
+
+// MapCollector is a collector that builds a map from incoming
+// items using functions to compute the key and the value of
+// each item.  Only the map is retained.
+type MapCollector struct {
+	keyFn  func(interface{}) interface{}
+	valFn  func(interface{}) interface{}
+	merge  MergeFunc
+	result map[interface{}]interface{}
+	input  <-chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// Map creates a *MapCollector that stores valFn(item) at key keyFn(item)
+// for each collected item. If valFn is nil, the item itself is stored.
+// By default, the last value collected for a key wins (see Merge).
+func Map(keyFn, valFn func(interface{}) interface{}) *MapCollector {
+	return &MapCollector{
+		keyFn:  keyFn,
+		valFn:  valFn,
+		merge:  LastWins,
+		result: make(map[interface{}]interface{}),
+	}
+}
+
+// Merge sets the function used to resolve values of duplicate keys.
+// Use LastWins, FirstWins, FailOnDuplicate or a custom merge function.
+func (c *MapCollector) Merge(merge MergeFunc) *MapCollector {
+	c.merge = merge
+	return c
+}
+
+// SetInput sets the channel input
+func (c *MapCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Get returns the collected map
+func (c *MapCollector) Get() map[interface{}]interface{} {
+	return c.result
+}
+
+// Open is the starting point that starts the collector.  If the merge
+// function returns an error, the collector stops and the error is
+// returned on the result channel.
+func (c *MapCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening map collector")
+	result := make(chan error)
+
+	if c.input == nil || c.keyFn == nil {
+		go func() { result <- errors.New("Map collector missing input or key function") }()
+		return result
+	}
+
+	go func() {
+		var err error
+		defer func() {
+			util.Logfn(c.logf, "Closing map collector")
+			if err != nil {
+				result <- err
+			}
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				key, val := c.keyFn(item), item
+				if c.valFn != nil {
+					val = c.valFn(item)
+				}
+				if existing, ok := c.result[key]; ok && c.merge != nil {
+					if val, err = c.merge(key, existing, val); err != nil {
+						util.Logfn(c.logf, err)
+						autoctx.Err(c.errf, api.Error(err.Error()))
+						return
+					}
+				}
+				c.result[key] = val
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
@@ -0,0 +1,61 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollector_Map(t *testing.T) {
+	keyFn := func(item interface{}) interface{} {
+		return item.([]string)[0]
+	}
+	valFn := func(item interface{}) interface{} {
+		return item.([]string)[1]
+	}
+	concat := func(key, existing, incoming interface{}) (interface{}, error) {
+		return existing.(string) + incoming.(string), nil
+	}
+
+	tests := []struct {
+		name       string
+		merge      MergeFunc
+		shouldFail bool
+		expected   interface{}
+	}{
+		{"last wins", LastWins, false, "c"},
+		{"first wins", FirstWins, false, "a"},
+		{"merge func", concat, false, "ac"},
+		{"fail on duplicate", FailOnDuplicate, true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mc := Map(keyFn, valFn).Merge(test.merge)
+			in := make(chan interface{}, 3)
+			in <- []string{"x", "a"}
+			in <- []string{"y", "b"}
+			in <- []string{"x", "c"}
+			close(in)
+			mc.SetInput(in)
+
+			select {
+			case err := <-mc.Open(context.TODO()):
+				if test.shouldFail {
+					if err == nil {
+						t.Fatal("expecting duplicate key error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mc.Get()["x"] != test.expected || mc.Get()["y"] != "b" {
+					t.Fatal("unexpected map ", mc.Get())
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Waited too long ...")
+			}
+		})
+	}
+}
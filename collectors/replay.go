@@ -0,0 +1,122 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// ReplayCollector is a collector that materializes collected items
+// so that they can be re-emitted into other streams for multi-pass
+// processing without re-reading the original source.
+type ReplayCollector struct {
+	items  []interface{}
+	closed bool
+	mutex  sync.RWMutex
+	input  <-chan interface{}
+	logf   api.LogFunc
+}
+
+// Replayable creates a new *ReplayCollector
+func Replayable() *ReplayCollector {
+	return new(ReplayCollector)
+}
+
+// SetInput sets the channel input
+func (r *ReplayCollector) SetInput(in <-chan interface{}) {
+	r.input = in
+}
+
+// Emitter returns a new source that re-emits the collected items
+// in their original order.  It can be called multiple times, each returned
+// source replays all items.  The returned source must only be opened after
+// the stream feeding this collector has closed, otherwise opening it
+// returns an error.
+func (r *ReplayCollector) Emitter() api.Source {
+	return &replayEmitter{
+		collector: r,
+		output:    make(chan interface{}, 1024),
+	}
+}
+
+// Open is the starting point that starts the collector
+func (r *ReplayCollector) Open(ctx context.Context) <-chan error {
+	r.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(r.logf, "Opening replay collector")
+	result := make(chan error)
+
+	if r.input == nil {
+		go func() { result <- errors.New("Replay collector missing input") }()
+		return result
+	}
+
+	go func() {
+		defer func() {
+			r.mutex.Lock()
+			r.closed = true
+			r.mutex.Unlock()
+			util.Logfn(r.logf, "Closing replay collector")
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-r.input:
+				if !opened {
+					return
+				}
+				r.mutex.Lock()
+				r.items = append(r.items, item)
+				r.mutex.Unlock()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// replayEmitter emits items materialized by a ReplayCollector
+type replayEmitter struct {
+	collector *ReplayCollector
+	output    chan interface{}
+	logf      api.LogFunc
+}
+
+// GetOutput returns the output channel of this source node
+func (e *replayEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the source node to start replaying items
+func (e *replayEmitter) Open(ctx context.Context) error {
+	e.collector.mutex.RLock()
+	closed, items := e.collector.closed, e.collector.items
+	e.collector.mutex.RUnlock()
+	if !closed {
+		return errors.New("replay collector is not closed")
+	}
+
+	e.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(e.logf, "Opening replay emitter")
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "Closing replay emitter")
+			close(e.output)
+		}()
+		for _, item := range items {
+			select {
+			case e.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
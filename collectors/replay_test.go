@@ -0,0 +1,48 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollector_Replayable(t *testing.T) {
+	rc := Replayable()
+	in := make(chan interface{})
+	go func() {
+		in <- "A"
+		in <- "B"
+		in <- "C"
+		close(in)
+	}()
+	rc.SetInput(in)
+
+	// opening before collector closes should fail
+	if err := rc.Emitter().Open(context.TODO()); err == nil {
+		t.Fatal("expecting error when replaying an open collector")
+	}
+
+	select {
+	case err := <-rc.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	// replay twice
+	for i := 0; i < 2; i++ {
+		e := rc.Emitter()
+		if err := e.Open(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		var result []interface{}
+		for item := range e.GetOutput() {
+			result = append(result, item)
+		}
+		if len(result) != 3 || result[0] != "A" || result[2] != "C" {
+			t.Fatal("unexpected replayed items ", result)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// SQLCollector is a collector that executes a prepared statement (i.e. an
+// insert or update) for each item, within transactions of batchSize items.
+type SQLCollector struct {
+	db        *sql.DB
+	query     string
+	batchSize int
+	input     <-chan interface{}
+	logf      api.LogFunc
+	errf      api.ErrorFunc
+}
+
+// SQL creates a *SQLCollector that executes query, prepared once, for each
+// item with the item bound as the query parameters.  An item can be a
+// []interface{}, bound as positional parameters, or a struct (or a pointer
+// to a struct) whose fields tagged with a column name, as in
+//
+//   type Order struct {
+//       ID    int     `db:"id"`
+//       Total float64 `db:"total"`
+//   }
+//
+// are bound, in declaration order, as positional parameters.  Unexported
+// fields and fields without a db tag, or tagged with "-", are ignored.
+//
+// Items are executed within a transaction that is committed every batch size
+// items (100 by default, see WithBatchSize) and when the stream closes.  When
+// an item fails, the error is reported to the error function, with the item
+// attached, and the transaction is rolled back.  Each item of the batch that
+// was rolled back (or whose commit failed) is then also reported, with the
+// item attached, so that no item is silently lost (i.e. they can be recovered
+// with a dead-letter sink).  The next items start a new transaction.  When the
+// stream is cancelled, the pending transaction is rolled back and its items are
+// reported likewise.  The first rollback or commit error is returned by the
+// collector once the stream closes.
+func SQL(db *sql.DB, query string) *SQLCollector {
+	return &SQLCollector{
+		db:        db,
+		query:     query,
+		batchSize: 100,
+	}
+}
+
+// WithBatchSize sets the number of items executed within each transaction
+func (c *SQLCollector) WithBatchSize(n int) *SQLCollector {
+	c.batchSize = n
+	return c
+}
+
+// SetInput sets the input channel for the collector node
+func (c *SQLCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open opens the collector, prepares the statement and starts executing items
+func (c *SQLCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening SQL collector")
+	result := make(chan error, 1)
+
+	if c.db == nil {
+		result <- fmt.Errorf("SQL collector missing database")
+		close(result)
+		return result
+	}
+	if c.batchSize <= 0 {
+		result <- fmt.Errorf("SQL collector batch size must be greater than zero")
+		close(result)
+		return result
+	}
+
+	stmt, err := c.db.PrepareContext(ctx, c.query)
+	if err != nil {
+		result <- fmt.Errorf("SQL collector prepare: %s", err)
+		close(result)
+		return result
+	}
+
+	go func() {
+		var tx *sql.Tx
+		var txStmt *sql.Stmt
+		var pending []interface{} // items executed in tx
+		var failure error          // first rollback or commit error
+
+		defer func() {
+			stmt.Close()
+			util.Logfn(c.logf, "Closing SQL collector")
+			if failure != nil {
+				result <- failure
+			}
+			close(result)
+		}()
+
+		// discard reports each pending item, rolled back or not committed
+		discard := func(err error) {
+			if failure == nil {
+				failure = fmt.Errorf("SQL collector: %s", err)
+			}
+			for _, item := range pending {
+				c.report(fmt.Errorf("SQL collector: item discarded: %s", err), item)
+			}
+			tx, txStmt, pending = nil, nil, nil
+		}
+
+		commit := func() {
+			if err := tx.Commit(); err != nil {
+				discard(fmt.Errorf("commit failed: %s", err))
+				return
+			}
+			tx, txStmt, pending = nil, nil, nil
+		}
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					if tx != nil {
+						commit()
+					}
+					return
+				}
+				args, err := sqlArgs(item)
+				if err != nil {
+					c.report(err, item)
+					continue
+				}
+				if tx == nil {
+					if tx, err = c.db.BeginTx(ctx, nil); err != nil {
+						tx = nil
+						c.report(err, item)
+						continue
+					}
+					txStmt = tx.StmtContext(ctx, stmt)
+				}
+				if _, err := txStmt.ExecContext(ctx, args...); err != nil {
+					c.report(err, item)
+					tx.Rollback()
+					discard(fmt.Errorf("transaction rolled back: %s", err))
+					continue
+				}
+				pending = append(pending, item)
+				if len(pending) >= c.batchSize {
+					commit()
+				}
+			case <-ctx.Done():
+				if tx != nil {
+					tx.Rollback()
+					discard(fmt.Errorf("transaction rolled back: %s", ctx.Err()))
+				}
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// report logs and reports err, with item attached, to the error function
+func (c *SQLCollector) report(err error, item interface{}) {
+	util.Logfn(c.logf, err)
+	var streamItem *api.StreamItem
+	if item != nil {
+		streamItem = &api.StreamItem{Item: item}
+	}
+	autoctx.Err(c.errf, api.WrapError(err, streamItem))
+}
+
+// sqlArgs returns the query parameters bound for item
+func sqlArgs(item interface{}) ([]interface{}, error) {
+	if args, ok := item.([]interface{}); ok {
+		return args, nil
+	}
+
+	val := reflect.ValueOf(item)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("SQL collector: unsupported item type %T", item)
+	}
+
+	var args []interface{}
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		column := field.Tag.Get("db")
+		if field.PkgPath != "" || column == "" || column == "-" {
+			continue
+		}
+		args = append(args, val.Field(i).Interface())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("SQL collector: struct %T has no db tagged fields", item)
+	}
+	return args, nil
+}
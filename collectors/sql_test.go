@@ -0,0 +1,155 @@
+package collectors
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/testutil"
+)
+
+func TestCollector_SQL(t *testing.T) {
+	type order struct {
+		ID    int     `db:"id"`
+		Note  string  // not bound
+		Total float64 `db:"total"`
+	}
+
+	db := &testutil.SQLDB{}
+	c := SQL(db.Open(), "INSERT INTO orders(id, total) VALUES(?, ?)").WithBatchSize(2)
+	in := make(chan interface{})
+	go func() {
+		in <- []interface{}{1, 10.5}
+		in <- order{ID: 2, Note: "n", Total: 20}
+		in <- &order{ID: 3, Total: 30}
+		close(in)
+	}()
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.Background()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := [][]driver.Value{{int64(1), 10.5}, {int64(2), 20.0}, {int64(3), 30.0}}
+	if !reflect.DeepEqual(db.Executed(), expected) {
+		t.Fatal("unexpected executed statements:", db.Executed())
+	}
+	if db.Commits() != 2 {
+		t.Fatal("expecting a commit per batch, got", db.Commits())
+	}
+}
+
+func TestCollector_SQL_Rollback(t *testing.T) {
+	db := &testutil.SQLDB{
+		ExecErr: func(args []driver.Value) error {
+			if args[0] == int64(2) {
+				return errors.New("constraint violation")
+			}
+			return nil
+		},
+	}
+	c := SQL(db.Open(), "INSERT INTO items(id) VALUES(?)").WithBatchSize(2)
+	in := make(chan interface{})
+	go func() {
+		for _, id := range []int{1, 2, 3, 4} {
+			in <- []interface{}{id}
+		}
+		in <- "unsupported"
+		close(in)
+	}()
+	c.SetInput(in)
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+
+	select {
+	case err := <-c.Open(ctx):
+		if err == nil || !strings.Contains(err.Error(), "constraint violation") {
+			t.Fatal("expecting rollback error, got", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	// batch with the failing item is rolled back, the next batch is committed
+	expected := [][]driver.Value{{int64(3)}, {int64(4)}}
+	if !reflect.DeepEqual(db.Executed(), expected) {
+		t.Fatal("unexpected executed statements:", db.Executed())
+	}
+	if db.Rollbacks() != 1 {
+		t.Fatal("expecting one rollback, got", db.Rollbacks())
+	}
+	// failing item, rolled back item and unsupported item are all reported
+	var reported []interface{}
+	for _, err := range errs {
+		if err.Item() == nil {
+			t.Fatal("expecting error with item, got", err)
+		}
+		reported = append(reported, err.Item().Item)
+	}
+	expectedErrs := []interface{}{[]interface{}{2}, []interface{}{1}, "unsupported"}
+	if !reflect.DeepEqual(reported, expectedErrs) {
+		t.Fatal("unexpected reported items:", reported)
+	}
+}
+
+func TestCollector_SQL_Cancel(t *testing.T) {
+	db := &testutil.SQLDB{}
+	c := SQL(db.Open(), "INSERT INTO items(id) VALUES(?)").WithBatchSize(10)
+	in := make(chan interface{})
+	c.SetInput(in)
+
+	var errs []api.StreamError
+	ctx, cancel := context.WithCancel(autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	}))
+	result := c.Open(ctx)
+	in <- []interface{}{1}
+	in <- []interface{}{2}
+	cancel()
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("expecting rollback error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if db.Commits() != 0 {
+		t.Fatal("unexpected commits:", db.Commits())
+	}
+	// both items are reported, whether executed before the rollback or not
+	reported := make(map[int]bool)
+	for _, err := range errs {
+		if err.Item() == nil {
+			t.Fatal("expecting error with item, got", err)
+		}
+		reported[err.Item().Item.([]interface{})[0].(int)] = true
+	}
+	if !reported[1] || !reported[2] {
+		t.Fatal("expecting items 1 and 2 reported, got", errs)
+	}
+}
+
+func TestCollector_SQL_MissingDB(t *testing.T) {
+	c := SQL(nil, "INSERT INTO items(id) VALUES(?)")
+	c.SetInput(make(chan interface{}))
+	if err := <-c.Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing database")
+	}
+}
@@ -0,0 +1,78 @@
+//go:build go1.18
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// TypedCollector collects stream items into a slice of type []T
+type TypedCollector[T any] struct {
+	slice []T
+	input <-chan interface{}
+	logf  api.LogFunc
+}
+
+// Typed creates a *TypedCollector that collects items into a []T,
+// removing the need to type-assert each collected item, for instance:
+//
+//   snk := collectors.Typed[string]()
+//   strm.Into(snk)
+//   ...
+//   words := snk.Get() // []string
+//
+// When an item is not of type T, the collector stops and the error
+// is returned on its error channel (which aborts the stream).
+func Typed[T any]() *TypedCollector[T] {
+	return new(TypedCollector[T])
+}
+
+// SetInput sets the input channel for the collector node
+func (s *TypedCollector[T]) SetInput(in <-chan interface{}) {
+	s.input = in
+}
+
+// Get returns the collected items
+func (s *TypedCollector[T]) Get() []T {
+	return s.slice
+}
+
+// Open opens the collector and starts collecting items
+func (s *TypedCollector[T]) Open(ctx context.Context) <-chan error {
+	s.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(s.logf, "Opening typed collector")
+	result := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			close(result)
+			util.Logfn(s.logf, "Closing typed collector")
+		}()
+
+		for {
+			select {
+			case item, opened := <-s.input:
+				if !opened {
+					return
+				}
+				val, ok := item.(T)
+				if !ok {
+					result <- fmt.Errorf("typed collector: item of type %T is not assignable to %s",
+						item, reflect.TypeOf((*T)(nil)).Elem())
+					return
+				}
+				s.slice = append(s.slice, val)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
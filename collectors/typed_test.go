@@ -0,0 +1,53 @@
+//go:build go1.18
+
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollector_Typed(t *testing.T) {
+	snk := Typed[string]()
+	in := make(chan interface{})
+	go func() {
+		in <- "A"
+		in <- "B"
+		in <- "C"
+		close(in)
+	}()
+	snk.SetInput(in)
+
+	select {
+	case err := <-snk.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(snk.Get(), []string{"A", "B", "C"}) {
+			t.Fatal("unexpected result:", snk.Get())
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestCollector_Typed_Error(t *testing.T) {
+	snk := Typed[string]()
+	in := make(chan interface{}, 2)
+	in <- "A"
+	in <- 42
+	close(in)
+	snk.SetInput(in)
+
+	select {
+	case err := <-snk.Open(context.TODO()):
+		expected := "typed collector: item of type int is not assignable to string"
+		if err == nil || err.Error() != expected {
+			t.Fatal("expecting type error, got", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
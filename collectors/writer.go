@@ -10,19 +10,32 @@ import (
 	"github.com/taiyang-li/automi/util"
 )
 
+// WriterCollector is a collector that writes collected items to an io.Writer:
+// []byte items are written directly, strings are written as-is, and other
+// items are written using their fmt representation (%v).
 type WriterCollector struct {
-	writer io.Writer
-	input  <-chan interface{}
-	logf   api.LogFunc
-	errf   api.ErrorFunc
+	writer  io.Writer
+	newline bool
+	input   <-chan interface{}
+	logf    api.LogFunc
+	errf    api.ErrorFunc
 }
 
+// Writer creates a *WriterCollector that writes to writer.  If writer
+// implements a Flush method (i.e. *bufio.Writer or http.Flusher), it is
+// flushed when the collector closes.
 func Writer(writer io.Writer) *WriterCollector {
 	return &WriterCollector{
 		writer: writer,
 	}
 }
 
+// WithNewline appends a newline after each written item
+func (c *WriterCollector) WithNewline() *WriterCollector {
+	c.newline = true
+	return c
+}
+
 func (c *WriterCollector) SetInput(in <-chan interface{}) {
 	c.input = in
 }
@@ -32,12 +45,17 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 	c.errf = autoctx.GetErrFunc(ctx)
 
 	util.Logfn(c.logf, "Opening io.Writer collector")
-	result := make(chan error)
+	result := make(chan error, 1)
 
 	go func() {
 		defer func() {
-			close(result)
 			util.Logfn(c.logf, "Closing io.Writer collector")
+			if err := flushWriter(c.writer); err != nil {
+				err = fmt.Errorf("io.Writer collector flush: %s", err)
+				util.Logfn(c.logf, err)
+				result <- err
+			}
+			close(result)
 		}()
 
 		for {
@@ -70,6 +88,12 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 						continue
 					}
 				}
+				if c.newline {
+					if _, err := io.WriteString(c.writer, "\n"); err != nil {
+						util.Logfn(c.logf, err)
+						autoctx.Err(c.errf, api.Error(err.Error()))
+					}
+				}
 			case <-ctx.Done():
 				return
 			}
@@ -78,3 +102,14 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 
 	return result
 }
+
+// flushWriter flushes writer if it implements a Flush method
+func flushWriter(writer io.Writer) error {
+	switch w := writer.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Flush() }:
+		w.Flush()
+	}
+	return nil
+}
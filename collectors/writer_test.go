@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"strings"
@@ -59,3 +60,52 @@ func TestCollector_Writer_String(t *testing.T) {
 	}
 
 }
+
+func TestCollector_Writer_Newline(t *testing.T) {
+	sink := bytes.NewBufferString("")
+	w := Writer(sink).WithNewline()
+	in := make(chan interface{})
+	go func() {
+		in <- "hello"
+		in <- []byte("world")
+		in <- 42
+		close(in)
+	}()
+	w.SetInput(in)
+	expected := "hello\nworld\n42\n"
+	select {
+	case err := <-w.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sink.String() != expected {
+			t.Fatalf("unexpected result %q", sink.String())
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestCollector_Writer_Flush(t *testing.T) {
+	sink := bytes.NewBufferString("")
+	buf := bufio.NewWriter(sink)
+	w := Writer(buf).WithNewline()
+	in := make(chan interface{})
+	go func() {
+		in <- "hello"
+		in <- "world"
+		close(in)
+	}()
+	w.SetInput(in)
+	select {
+	case err := <-w.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sink.String() != "hello\nworld\n" {
+			t.Fatalf("writer not flushed: %q", sink.String())
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
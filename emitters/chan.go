@@ -18,7 +18,9 @@ type ChanEmitter struct {
 	logf    api.LogFunc
 }
 
-// Chan creates new slice source
+// Chan creates a new channel source from any channel value (i.e. chan T
+// or <-chan T).  The emitter streams items received from the channel and
+// closes its output when the channel is closed or the stream context is done.
 func Chan(channel interface{}) *ChanEmitter {
 	return &ChanEmitter{
 		channel: channel,
@@ -33,35 +35,42 @@ func (c *ChanEmitter) GetOutput() <-chan interface{} {
 
 // Open opens the source node to start streaming data on its channel
 func (c *ChanEmitter) Open(ctx context.Context) error {
-	// ensure channel param is a chan type
+	// ensure channel param is a receivable chan type
 	chanType := reflect.TypeOf(c.channel)
-	if chanType.Kind() != reflect.Chan {
+	if chanType == nil || chanType.Kind() != reflect.Chan {
 		return errors.New("ChanEmitter requires channel")
 	}
+	if chanType.ChanDir()&reflect.RecvDir == 0 {
+		return errors.New("ChanEmitter requires a receive channel")
+	}
 	c.logf = autoctx.GetLogFunc(ctx)
 	util.Logfn(c.logf, "Opening channel emitter")
 	chanVal := reflect.ValueOf(c.channel)
 
-	if !chanVal.IsValid() {
+	if !chanVal.IsValid() || chanVal.IsNil() {
 		return errors.New("invalid channel for ChanEmitter")
 	}
 
 	go func() {
-		exeCtx, cancel := context.WithCancel(ctx)
 		defer func() {
-			util.Logfn(c.logf, "Slice emitter closing")
-			cancel()
+			util.Logfn(c.logf, "Channel emitter closing")
 			close(c.output)
 		}()
 
+		// select on both the channel and the context so that a
+		// cancelled stream does not block on an idle channel
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: chanVal},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
 		for {
-			val, open := chanVal.Recv()
-			if !open {
+			chosen, val, open := reflect.Select(cases)
+			if chosen == 1 || !open {
 				return
 			}
 			select {
 			case c.output <- val.Interface():
-			case <-exeCtx.Done():
+			case <-ctx.Done():
 				return
 			}
 		}
@@ -59,3 +59,40 @@ func TestEmitter_Chan(t *testing.T) {
 	}
 
 }
+
+func TestEmitter_Chan_Cancel(t *testing.T) {
+	ch := make(chan string) // never written nor closed
+	e := Chan(ch)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case _, open := <-e.GetOutput():
+		if open {
+			t.Fatal("unexpected item")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("emitter did not stop on cancelled context")
+	}
+}
+
+func TestEmitter_Chan_Invalid(t *testing.T) {
+	var nilCh chan int
+	tests := []struct {
+		name    string
+		channel interface{}
+	}{
+		{"not a channel", []int{1}},
+		{"nil value", nil},
+		{"nil channel", nilCh},
+		{"send-only channel", make(chan<- int)},
+	}
+	for _, test := range tests {
+		if err := Chan(test.channel).Open(context.Background()); err == nil {
+			t.Fatalf("%s: expecting error", test.name)
+		}
+	}
+}
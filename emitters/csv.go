@@ -58,12 +58,22 @@ func (c *CsvEmitter) CommentChar(char rune) *CsvEmitter {
 	return c
 }
 
-// HasHeaders indicates that data source has header record
+// HasHeaders indicates that data source has header record.
+// The header record is skipped and not emitted downstream.
 func (c *CsvEmitter) HasHeaders() *CsvEmitter {
 	c.hasHeaders = true
 	return c
 }
 
+// FieldsPerRecord sets the number of fields expected in each record.
+// If n is positive, records with a different field count are reported
+// as errors.  If n is 0 (default), the count is set by the header (or
+// first) record.  If n is negative, records may have a variable count.
+func (c *CsvEmitter) FieldsPerRecord(n int) *CsvEmitter {
+	c.fieldCount = n
+	return c
+}
+
 // init internal initialization method
 func (c *CsvEmitter) init(ctx context.Context) error {
 	c.logf = autoctx.GetLogFunc(ctx)
@@ -90,17 +100,20 @@ func (c *CsvEmitter) init(ctx context.Context) error {
 	c.csvReader.Comma = c.delimChar
 	c.csvReader.TrimLeadingSpace = true
 	c.csvReader.LazyQuotes = true
+	c.csvReader.FieldsPerRecord = c.fieldCount
 
 	// resolve header and field count
 	if c.hasHeaders {
 		if headers, err := c.csvReader.Read(); err == nil {
-			c.fieldCount = len(headers)
+			if c.fieldCount == 0 {
+				c.fieldCount = len(headers)
+			}
 			c.headers = headers
 		} else {
 			return fmt.Errorf("Unable to read header row: %s", err)
 		}
 	} else {
-		if c.headers != nil {
+		if c.headers != nil && c.fieldCount == 0 {
 			c.fieldCount = len(c.headers)
 		}
 	}
@@ -125,8 +138,7 @@ func (c *CsvEmitter) Open(ctx context.Context) (err error) {
 		defer func() {
 			util.Logfn(c.logf, "CSV emitter closing")
 			if c.file != nil {
-				err = c.file.Close()
-				if err != nil {
+				if err := c.file.Close(); err != nil {
 					util.Logfn(c.logf, err)
 					autoctx.Err(c.errf, api.Error(err.Error()))
 				}
@@ -143,7 +155,11 @@ func (c *CsvEmitter) Open(ctx context.Context) (err error) {
 				}
 				util.Logfn(c.logf, fmt.Errorf("Error reading row: %s", err))
 				autoctx.Err(c.errf, api.Error(err.Error()))
-				continue
+				// skip malformed rows, stop on any other read error
+				if _, ok := err.(*csv.ParseError); ok {
+					continue
+				}
+				return
 			}
 
 			select {
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
 	"github.com/taiyang-li/automi/testutil"
 )
 
@@ -166,3 +169,84 @@ func Benchmark_CSV(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestEmitter_CSV_Options(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		emitter   func(io.Reader) *CsvEmitter
+		rows      int
+		errors    int
+		firstCell string
+	}{
+		{
+			name:      "delimiter",
+			data:      "a|b\nc|d",
+			emitter:   func(r io.Reader) *CsvEmitter { return CSV(r).DelimChar('|') },
+			rows:      2,
+			firstCell: "a",
+		},
+		{
+			name:      "skip header",
+			data:      "h1,h2\na,b\nc,d",
+			emitter:   func(r io.Reader) *CsvEmitter { return CSV(r).HasHeaders() },
+			rows:      2,
+			firstCell: "a",
+		},
+		{
+			name:      "fields per record",
+			data:      "a,b\nc\nd,e,f\ng,h",
+			emitter:   func(r io.Reader) *CsvEmitter { return CSV(r).FieldsPerRecord(2) },
+			rows:      2,
+			errors:    2,
+			firstCell: "a",
+		},
+		{
+			name:      "fields from header",
+			data:      "h1,h2\na,b,c\nd,e",
+			emitter:   func(r io.Reader) *CsvEmitter { return CSV(r).HasHeaders() },
+			rows:      1,
+			errors:    1,
+			firstCell: "d",
+		},
+		{
+			name:      "variable fields",
+			data:      "a\nb,c\nd,e,f",
+			emitter:   func(r io.Reader) *CsvEmitter { return CSV(r).FieldsPerRecord(-1) },
+			rows:      3,
+			firstCell: "a",
+		},
+	}
+
+	for _, test := range tests {
+		var m sync.Mutex
+		var errs []api.StreamError
+		ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+			m.Lock()
+			errs = append(errs, err)
+			m.Unlock()
+		})
+
+		csv := test.emitter(strings.NewReader(test.data))
+		if err := csv.Open(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		var rows [][]string
+		for row := range csv.GetOutput() {
+			rows = append(rows, row.([]string))
+		}
+
+		if len(rows) != test.rows {
+			t.Fatalf("%s: expecting %d rows, got %v", test.name, test.rows, rows)
+		}
+		if rows[0][0] != test.firstCell {
+			t.Fatalf("%s: unexpected first row %v", test.name, rows[0])
+		}
+		m.Lock()
+		if len(errs) != test.errors {
+			t.Fatalf("%s: expecting %d errors, got %v", test.name, test.errors, errs)
+		}
+		m.Unlock()
+	}
+}
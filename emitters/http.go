@@ -0,0 +1,132 @@
+package emitters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// HTTPEmitter is an emitter that performs an HTTP request and emits the
+// response body line by line, as strings, or as decoded JSON values for
+// line-delimited JSON (NDJSON) responses.
+type HTTPEmitter struct {
+	req      *http.Request
+	client   *http.Client
+	jsonMode bool
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+// HTTP creates an *HTTPEmitter that performs request req when opened.  The
+// request is bound to the stream context, so cancelling the stream aborts
+// the request.  A response with a non-2xx status is reported to the error
+// function and nothing is emitted.
+func HTTP(req *http.Request) *HTTPEmitter {
+	return &HTTPEmitter{
+		req:    req,
+		client: http.DefaultClient,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// WithClient sets the client used to perform the request
+// (http.DefaultClient by default)
+func (e *HTTPEmitter) WithClient(client *http.Client) *HTTPEmitter {
+	e.client = client
+	return e
+}
+
+// JSON decodes each non-empty line of the response body as JSON and emits
+// it as a map[string]interface{}.  Lines that fail to decode are reported to
+// the error function, with the line attached, and are skipped.
+func (e *HTTPEmitter) JSON() *HTTPEmitter {
+	e.jsonMode = true
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *HTTPEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the emitter which performs the request and starts emitting
+func (e *HTTPEmitter) Open(ctx context.Context) error {
+	if e.req == nil {
+		return errors.New("HTTP emitter missing request")
+	}
+	if e.client == nil {
+		return errors.New("HTTP emitter missing client")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "HTTP emitter starting")
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "HTTP emitter closing")
+			close(e.output)
+		}()
+
+		resp, err := e.client.Do(e.req.WithContext(ctx))
+		if err != nil {
+			if ctx.Err() == nil {
+				e.report(api.WrapError(err, nil))
+			}
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			e.report(api.Error(fmt.Sprintf("HTTP emitter: unexpected status %s", resp.Status)))
+			return
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			item, ok := e.decode(scanner.Text())
+			if !ok {
+				continue
+			}
+			select {
+			case e.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			e.report(api.WrapError(err, nil))
+		}
+	}()
+	return nil
+}
+
+// decode returns the item to emit for line, in JSON mode it returns
+// false for empty lines and lines that fail to decode.
+func (e *HTTPEmitter) decode(line string) (interface{}, bool) {
+	if !e.jsonMode {
+		return line, true
+	}
+	if line == "" {
+		return nil, false
+	}
+	var item map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &item); err != nil {
+		e.report(api.WrapError(err, &api.StreamItem{Item: line}))
+		return nil, false
+	}
+	return item, true
+}
+
+// report logs and reports err to the error function
+func (e *HTTPEmitter) report(err api.StreamError) {
+	util.Logfn(e.logf, err)
+	autoctx.Err(e.errf, err)
+}
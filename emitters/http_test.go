@@ -0,0 +1,143 @@
+package emitters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func collectHTTP(t *testing.T, e *HTTPEmitter, ctx context.Context) []interface{} {
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case item, opened := <-e.GetOutput():
+			if !opened {
+				return items
+			}
+			items = append(items, item)
+		case <-timeout:
+			t.Fatal("Waited too long ...")
+		}
+	}
+}
+
+func TestEmitter_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"id\":1}\n\nnot json\n{\"id\":2}\n")
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := collectHTTP(t, HTTP(req), context.Background())
+	expected := []interface{}{`{"id":1}`, "", "not json", `{"id":2}`}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatal("unexpected lines:", items)
+	}
+
+	var m sync.Mutex
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+	items = collectHTTP(t, HTTP(req).JSON(), ctx)
+	expected = []interface{}{
+		map[string]interface{}{"id": 1.0},
+		map[string]interface{}{"id": 2.0},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatal("unexpected JSON items:", items)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 1 || errs[0].Item() == nil || errs[0].Item().Item != "not json" {
+		t.Fatal("expecting decode error with line, got", errs)
+	}
+}
+
+func TestEmitter_HTTP_Status(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	var m sync.Mutex
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	items := collectHTTP(t, HTTP(req), ctx)
+	if len(items) != 0 {
+		t.Fatal("unexpected items:", items)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 1 || errs[0].Error() != "HTTP emitter: unexpected status 404 Not Found" {
+		t.Fatal("expecting status error, got", errs)
+	}
+}
+
+func TestEmitter_HTTP_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "first")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	e := HTTP(req)
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case item := <-e.GetOutput():
+		if item != "first" {
+			t.Fatal("unexpected item:", item)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waited too long ...")
+	}
+	cancel()
+	select {
+	case <-waitClosed(e.GetOutput()):
+	case <-time.After(time.Second):
+		t.Fatal("emitter not closed on cancel")
+	}
+}
+
+// waitClosed returns a channel closed once ch is drained and closed
+func waitClosed(ch <-chan interface{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
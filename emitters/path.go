@@ -0,0 +1,104 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// PathEmitter is an emitter that emits file paths, as strings, either
+// found by walking a directory tree or matching a glob pattern.
+type PathEmitter struct {
+	root    string
+	pattern string
+	output  chan interface{}
+	logf    api.LogFunc
+	errf    api.ErrorFunc
+}
+
+// Walk creates a *PathEmitter that walks the directory tree rooted at root,
+// in lexical order, and emits the path of each regular file.  Errors
+// encountered while walking (i.e. an unreadable directory) are reported to
+// the error function and the walk continues.
+func Walk(root string) *PathEmitter {
+	return &PathEmitter{
+		root:   root,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// Glob creates a *PathEmitter that emits the paths matching pattern, using the
+// syntax of filepath.Match.  A malformed pattern fails the emitter when opened.
+func Glob(pattern string) *PathEmitter {
+	return &PathEmitter{
+		pattern: pattern,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (e *PathEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the source node to start emitting paths
+func (e *PathEmitter) Open(ctx context.Context) error {
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Opening path emitter")
+
+	if e.pattern != "" {
+		paths, err := filepath.Glob(e.pattern)
+		if err != nil {
+			return fmt.Errorf("path emitter: %s", err)
+		}
+		go func() {
+			defer func() {
+				util.Logfn(e.logf, "Path emitter closing")
+				close(e.output)
+			}()
+			for _, path := range paths {
+				select {
+				case e.output <- path:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return nil
+	}
+
+	if e.root == "" {
+		return errors.New("path emitter missing root or pattern")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "Path emitter closing")
+			close(e.output)
+		}()
+		filepath.Walk(e.root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				util.Logfn(e.logf, err)
+				autoctx.Err(e.errf, api.WrapError(err, &api.StreamItem{Item: path}))
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			select {
+			case e.output <- path:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err() // stop walking
+			}
+		})
+	}()
+	return nil
+}
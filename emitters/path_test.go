@@ -0,0 +1,94 @@
+package emitters
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+// makeTree creates files a.txt, b.log and sub/c.txt under a temp dir
+func makeTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "automi-path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "b.log", filepath.Join("sub", "c.txt")} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func collectPaths(t *testing.T, e *PathEmitter, ctx context.Context) []string {
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	timeout := time.After(50 * time.Millisecond)
+	for {
+		select {
+		case item, opened := <-e.GetOutput():
+			if !opened {
+				return paths
+			}
+			paths = append(paths, item.(string))
+		case <-timeout:
+			t.Fatal("Waited too long ...")
+		}
+	}
+}
+
+func TestEmitter_Walk(t *testing.T) {
+	root := makeTree(t)
+	defer os.RemoveAll(root)
+
+	paths := collectPaths(t, Walk(root), context.Background())
+	expected := []string{
+		filepath.Join(root, "a.txt"),
+		filepath.Join(root, "b.log"),
+		filepath.Join(root, "sub", "c.txt"),
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatal("unexpected paths:", paths)
+	}
+}
+
+func TestEmitter_Walk_Error(t *testing.T) {
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+
+	paths := collectPaths(t, Walk(filepath.Join(os.TempDir(), "automi-missing-dir")), ctx)
+	if len(paths) != 0 {
+		t.Fatal("unexpected paths:", paths)
+	}
+	if len(errs) != 1 || !os.IsNotExist(errs[0].Cause()) {
+		t.Fatal("expecting walk error reported, got", errs)
+	}
+}
+
+func TestEmitter_Glob(t *testing.T) {
+	root := makeTree(t)
+	defer os.RemoveAll(root)
+
+	paths := collectPaths(t, Glob(filepath.Join(root, "*.txt")), context.Background())
+	if !reflect.DeepEqual(paths, []string{filepath.Join(root, "a.txt")}) {
+		t.Fatal("unexpected paths:", paths)
+	}
+
+	if err := Glob("[").Open(context.Background()); err == nil {
+		t.Fatal("expecting error for malformed pattern")
+	}
+}
@@ -19,6 +19,7 @@ import (
 type ScannerEmitter struct {
 	rdrParam   io.Reader
 	spltrParam bufio.SplitFunc
+	maxToken   int
 	scanner    *bufio.Scanner
 	output     chan interface{}
 	logf       api.LogFunc
@@ -37,6 +38,14 @@ func Scanner(reader io.Reader, splitter bufio.SplitFunc) *ScannerEmitter {
 	}
 }
 
+// MaxTokenSize sets the maximum size of a token (i.e. a line) that can be
+// scanned, the default is bufio.MaxScanTokenSize (64KB).  Scanning stops
+// with an error, reported to the error function, when a token is larger.
+func (e *ScannerEmitter) MaxTokenSize(size int) *ScannerEmitter {
+	e.maxToken = size
+	return e
+}
+
 // GetOutput returns the output channel of this source node
 func (e *ScannerEmitter) GetOutput() <-chan interface{} {
 	return e.output
@@ -63,16 +72,18 @@ func (e *ScannerEmitter) Open(ctx context.Context) error {
 		}()
 
 		for e.scanner.Scan() {
-			if err := e.scanner.Err(); err != nil {
-				util.Logfn(e.logf, fmt.Errorf("Scanner emitter error: %s", err))
-				autoctx.Err(e.errf, api.Error(err.Error()))
-			}
 			select {
 			case e.output <- e.scanner.Text():
 			case <-exeCtx.Done():
 				return
 			}
 		}
+
+		// scanning stops on first error (other than io.EOF)
+		if err := e.scanner.Err(); err != nil {
+			util.Logfn(e.logf, fmt.Errorf("Scanner emitter error: %s", err))
+			autoctx.Err(e.errf, api.Error(err.Error()))
+		}
 	}()
 	return nil
 }
@@ -88,5 +99,13 @@ func (e *ScannerEmitter) setupScanner() error {
 	if e.spltrParam != nil {
 		e.scanner.Split(e.spltrParam)
 	}
+
+	if e.maxToken > 0 {
+		initial := e.maxToken
+		if initial > 4096 {
+			initial = 4096
+		}
+		e.scanner.Buffer(make([]byte, 0, initial), e.maxToken)
+	}
 	return nil
 }
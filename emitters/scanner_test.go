@@ -7,6 +7,9 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
 )
 
 func TestEmitter_Scanner(t *testing.T) {
@@ -64,3 +67,45 @@ func TestEmitter_Scanner(t *testing.T) {
 		m.Unlock()
 	}
 }
+
+func TestEmitter_Scanner_MaxTokenSize(t *testing.T) {
+	longLine := strings.Repeat("x", 100*1024)
+	tests := []struct {
+		name     string
+		maxToken int
+		lines    int
+		errCount int
+	}{
+		{"default buffer", 0, 1, 1},
+		{"large buffer", 200 * 1024, 3, 0},
+	}
+
+	for _, test := range tests {
+		var m sync.Mutex
+		var errs []api.StreamError
+		ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+			m.Lock()
+			errs = append(errs, err)
+			m.Unlock()
+		})
+
+		data := "first\n" + longLine + "\nlast"
+		e := Scanner(strings.NewReader(data), nil).MaxTokenSize(test.maxToken)
+		if err := e.Open(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := 0
+		for range e.GetOutput() {
+			lines++
+		}
+		if lines != test.lines {
+			t.Fatalf("%s: expecting %d lines, got %d", test.name, test.lines, lines)
+		}
+		m.Lock()
+		if len(errs) != test.errCount {
+			t.Fatalf("%s: expecting %d errors, got %v", test.name, test.errCount, errs)
+		}
+		m.Unlock()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"time"
 
 	"github.com/taiyang-li/automi/api"
 	autoctx "github.com/taiyang-li/automi/api/context"
@@ -13,9 +14,11 @@ import (
 // SliceEmitter is an emitter that takes in a slice and
 // emits slice items individually as a stream.
 type SliceEmitter struct {
-	slice  interface{}
-	output chan interface{}
-	logf   api.LogFunc
+	slice    interface{}
+	offset   int64
+	interval time.Duration
+	output   chan interface{}
+	logf     api.LogFunc
 }
 
 // SliceSrc creates new slice source
@@ -26,6 +29,21 @@ func Slice(slice interface{}) *SliceEmitter {
 	}
 }
 
+// SliceWithInterval creates a new slice source that waits for duration d
+// between emitted items, which paces the stream (i.e. to exercise time-based
+// operators).  Waits are interrupted when the stream context is cancelled.
+func SliceWithInterval(slice interface{}, d time.Duration) *SliceEmitter {
+	s := Slice(slice)
+	s.interval = d
+	return s
+}
+
+// Resume sets the emitter to skip the first offset items of the slice,
+// to resume a stream from a saved offset.
+func (s *SliceEmitter) Resume(offset int64) {
+	s.offset = offset
+}
+
 // GetOuptut returns the output channel of this source node
 func (s *SliceEmitter) GetOutput() <-chan interface{} {
 	return s.output
@@ -53,7 +71,18 @@ func (s *SliceEmitter) Open(ctx context.Context) error {
 			cancel()
 			close(s.output)
 		}()
-		for i := 0; i < sliceVal.Len(); i++ {
+		start := 0
+		if s.offset > 0 {
+			start = int(s.offset)
+		}
+		for i := start; i < sliceVal.Len(); i++ {
+			if i > start && s.interval > 0 {
+				select {
+				case <-time.After(s.interval):
+				case <-exeCtx.Done():
+					return
+				}
+			}
 			val := sliceVal.Index(i)
 			select {
 			case s.output <- val.Interface():
@@ -2,6 +2,7 @@ package emitters
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -36,3 +37,67 @@ func TestEmitter_Slice(t *testing.T) {
 	}
 	m.Unlock()
 }
+
+func TestEmitter_Slice_Resume(t *testing.T) {
+	tests := []struct {
+		offset   int64
+		expected []string
+	}{
+		{offset: 0, expected: []string{"A", "B", "C"}},
+		{offset: 2, expected: []string{"C"}},
+		{offset: 5, expected: nil},
+		{offset: -1, expected: []string{"A", "B", "C"}},
+	}
+	for _, test := range tests {
+		s := Slice([]string{"A", "B", "C"})
+		s.Resume(test.offset)
+		if err := s.Open(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var items []string
+		for item := range s.GetOutput() {
+			items = append(items, item.(string))
+		}
+		if !reflect.DeepEqual(items, test.expected) {
+			t.Fatalf("offset %d: unexpected items %v", test.offset, items)
+		}
+	}
+}
+
+func TestEmitter_SliceWithInterval(t *testing.T) {
+	s := SliceWithInterval([]string{"A", "B", "C"}, 10*time.Millisecond)
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	var times []time.Duration
+	for range s.GetOutput() {
+		times = append(times, time.Since(start))
+	}
+	if len(times) != 3 {
+		t.Fatal("unexpected item count ", len(times))
+	}
+	if times[0] > 5*time.Millisecond || times[2] < 20*time.Millisecond {
+		t.Fatal("items not paced:", times)
+	}
+}
+
+func TestEmitter_SliceWithInterval_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := SliceWithInterval([]string{"A", "B", "C"}, time.Hour)
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-s.GetOutput()
+	cancel()
+
+	select {
+	case _, opened := <-s.GetOutput():
+		if opened {
+			t.Fatal("unexpected item after cancel")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("emitter did not stop on cancel")
+	}
+}
@@ -0,0 +1,111 @@
+package emitters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// SQLEmitter is an emitter that runs a database query and emits its rows
+type SQLEmitter struct {
+	db     *sql.DB
+	query  string
+	args   []interface{}
+	slices bool
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// SQL creates a *SQLEmitter that runs query, with parameters args, when
+// opened and emits each row as a map[string]interface{} keyed by column
+// name.  The query is bound to the stream context and its rows are closed
+// when all rows are emitted or the stream is cancelled.  A failing query
+// fails the emitter when opened; rows that fail to scan are reported to the
+// error function and are skipped.
+func SQL(db *sql.DB, query string, args ...interface{}) *SQLEmitter {
+	return &SQLEmitter{
+		db:     db,
+		query:  query,
+		args:   args,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// AsSlices emits each row as a []interface{} of the column values, in
+// column order, instead of a map
+func (e *SQLEmitter) AsSlices() *SQLEmitter {
+	e.slices = true
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *SQLEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the emitter which runs the query and starts emitting rows
+func (e *SQLEmitter) Open(ctx context.Context) error {
+	if e.db == nil {
+		return errors.New("SQL emitter missing database")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Opening SQL emitter")
+
+	rows, err := e.db.QueryContext(ctx, e.query, e.args...)
+	if err != nil {
+		return fmt.Errorf("SQL emitter query: %s", err)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return fmt.Errorf("SQL emitter columns: %s", err)
+	}
+
+	go func() {
+		defer func() {
+			rows.Close()
+			util.Logfn(e.logf, "SQL emitter closing")
+			close(e.output)
+		}()
+
+		for rows.Next() {
+			values := make([]interface{}, len(columns))
+			dest := make([]interface{}, len(columns))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				util.Logfn(e.logf, err)
+				autoctx.Err(e.errf, api.WrapError(err, nil))
+				continue
+			}
+
+			var item interface{} = values
+			if !e.slices {
+				row := make(map[string]interface{}, len(columns))
+				for i, column := range columns {
+					row[column] = values[i]
+				}
+				item = row
+			}
+
+			select {
+			case e.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := rows.Err(); err != nil && ctx.Err() == nil {
+			util.Logfn(e.logf, err)
+			autoctx.Err(e.errf, api.WrapError(err, nil))
+		}
+	}()
+	return nil
+}
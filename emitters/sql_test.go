@@ -0,0 +1,125 @@
+package emitters
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/testutil"
+)
+
+func collectSQL(t *testing.T, e *SQLEmitter, ctx context.Context) []interface{} {
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case item, opened := <-e.GetOutput():
+			if !opened {
+				return items
+			}
+			items = append(items, item)
+		case <-timeout:
+			t.Fatal("Waited too long ...")
+		}
+	}
+}
+
+func TestEmitter_SQL(t *testing.T) {
+	db := &testutil.SQLDB{
+		Columns: []string{"id", "name"},
+		Rows:    [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}},
+	}
+
+	items := collectSQL(t, SQL(db.Open(), "SELECT id, name FROM items WHERE id > ?", 0), context.Background())
+	expected := []interface{}{
+		map[string]interface{}{"id": int64(1), "name": "a"},
+		map[string]interface{}{"id": int64(2), "name": "b"},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatal("unexpected rows:", items)
+	}
+
+	items = collectSQL(t, SQL(db.Open(), "SELECT id, name FROM items").AsSlices(), context.Background())
+	expected = []interface{}{
+		[]interface{}{int64(1), "a"},
+		[]interface{}{int64(2), "b"},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatal("unexpected rows:", items)
+	}
+	if db.RowsClosed() != 2 {
+		t.Fatal("expecting rows to be closed, got", db.RowsClosed())
+	}
+}
+
+func TestEmitter_SQL_RowsError(t *testing.T) {
+	db := &testutil.SQLDB{
+		Columns: []string{"id"},
+		Rows:    [][]driver.Value{{int64(1)}},
+		RowsErr: errors.New("connection reset"),
+	}
+
+	var m sync.Mutex
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+	items := collectSQL(t, SQL(db.Open(), "SELECT id FROM items"), ctx)
+	if len(items) != 1 {
+		t.Fatal("unexpected rows:", items)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 1 || errs[0].Error() != "connection reset" {
+		t.Fatal("expecting rows error, got", errs)
+	}
+}
+
+func TestEmitter_SQL_Cancel(t *testing.T) {
+	rows := make([][]driver.Value, 5000)
+	for i := range rows {
+		rows[i] = []driver.Value{int64(i)}
+	}
+	db := &testutil.SQLDB{Columns: []string{"id"}, Rows: rows}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := SQL(db.Open(), "SELECT id FROM items")
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-e.GetOutput()
+	cancel()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, opened := <-e.GetOutput():
+			if opened {
+				continue
+			}
+			if db.RowsClosed() != 1 {
+				t.Fatal("expecting rows to be closed on cancel")
+			}
+			return
+		case <-timeout:
+			t.Fatal("Waited too long ...")
+		}
+	}
+}
+
+func TestEmitter_SQL_MissingDB(t *testing.T) {
+	if err := SQL(nil, "SELECT 1").Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing database")
+	}
+}
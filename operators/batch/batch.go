@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/taiyang-li/automi/api"
 	autoctx "github.com/taiyang-li/automi/api/context"
@@ -14,10 +15,11 @@ import (
 // on provided criteria.  The batched items are streamed on the
 // ouptut channel for downstream processing.
 type BatchOperator struct {
-	input   <-chan interface{}
-	output  chan interface{}
-	logf    api.LogFunc
-	trigger api.BatchTrigger
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+	trigger  api.BatchTrigger
+	interval time.Duration
 }
 
 // New returns a new BatchOperator operator
@@ -27,6 +29,14 @@ func New() *BatchOperator {
 	return op
 }
 
+// SetBufferSize sets the buffer size of the output channel
+func (op *BatchOperator) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	op.output = make(chan interface{}, bufferSize)
+}
+
 // SetInput sets the input channel for the executor node
 func (op *BatchOperator) SetInput(in <-chan interface{}) {
 	op.input = in
@@ -42,6 +52,14 @@ func (op *BatchOperator) SetTrigger(trigger api.BatchTrigger) {
 	op.trigger = trigger
 }
 
+// SetFlushInterval sets a duration after which the current batch, if not
+// empty, is sent downstream, regardless of the batch trigger.  This lets
+// batches flush periodically on unbounded streams.  A duration <= 0 disables
+// the periodic flush.
+func (op *BatchOperator) SetFlushInterval(d time.Duration) {
+	op.interval = d
+}
+
 // Exec is the execution starting point for the operator node.
 // The batch operator batches N size items from upstream into
 // a slice []T.  When the slice reaches size N, the slice is sent
@@ -78,6 +96,14 @@ func (op *BatchOperator) Exec(ctx context.Context) (err error) {
 			op.trigger = TriggerAll()
 		}
 
+		// periodic flush, if any
+		var tick <-chan time.Time
+		if op.interval > 0 {
+			ticker := time.NewTicker(op.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
 		var index int64 = 1
 		for {
 			select {
@@ -109,6 +135,18 @@ func (op *BatchOperator) Exec(ctx context.Context) (err error) {
 					return
 				}
 
+			case <-tick:
+				if !batchValue.IsValid() || batchValue.Len() == 0 {
+					continue
+				}
+				select {
+				case op.output <- batchValue.Interface():
+					index = 1
+					batchValue = reflect.MakeSlice(batchValue.Type(), 0, 1)
+				case <-exeCtx.Done():
+					return
+				}
+
 			case <-exeCtx.Done():
 				return
 			}
@@ -2,6 +2,7 @@ package batch
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -158,6 +159,64 @@ func TestBatchOp_Exec_MultipleBatches(t *testing.T) {
 	}
 }
 
+func TestBatchOp_Exec_FlushInterval(t *testing.T) {
+	o := New()
+	o.SetTrigger(TriggerAll())
+	o.SetFlushInterval(20 * time.Millisecond)
+	in := make(chan interface{})
+	go func() {
+		in <- "A"
+		in <- "B"
+		time.Sleep(50 * time.Millisecond) // idle ticks emit nothing
+		in <- "C"
+		close(in)
+	}()
+	o.SetInput(in)
+
+	if err := o.Exec(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var batches [][]string
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for data := range o.GetOutput() {
+			batches = append(batches, data.([]string))
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Took too long...")
+	}
+	expected := [][]string{{"A", "B"}, {"C"}}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Fatal("unexpected batches:", batches)
+	}
+}
+
+func TestBatchOp_Exec_FlushInterval_Cancel(t *testing.T) {
+	o := New()
+	o.SetFlushInterval(time.Millisecond)
+	o.SetInput(make(chan interface{})) // never closed
+	ctx, cancel := context.WithCancel(context.TODO())
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case _, opened := <-o.GetOutput():
+		if opened {
+			t.Fatal("unexpected batch")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("batch operator did not stop on cancel")
+	}
+}
+
 func TestBatchOp_BatchSlice(t *testing.T) {
 	o := New()
 
@@ -2,11 +2,13 @@ package batch
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/api/tuple"
 	"github.com/taiyang-li/automi/util"
 )
 
@@ -107,6 +109,11 @@ func SumByPosFunc(pos int) api.UnFunc {
 // The function returns a type
 //   []map[interface{}][]interface{}
 // Where the map that uses the field values as key to group the items.
+// A field is selected by its automi struct tag first, as in
+//   type Order struct {
+//       CustomerID string `automi:"customer"`
+//   }
+// then by its field name when no tag matches.
 func GroupByNameFunc(name string) api.UnFunc {
 	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
 		dataType := reflect.TypeOf(param0)
@@ -116,7 +123,6 @@ func GroupByNameFunc(name string) api.UnFunc {
 		if dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array {
 			return param0 // ignores the data
 		}
-		name = strings.Title(name) // avoid unexported field panic
 		group := make(map[interface{}][]interface{})
 
 		groupItems := func(key, value reflect.Value, grp map[interface{}][]interface{}) {
@@ -132,14 +138,14 @@ func GroupByNameFunc(name string) api.UnFunc {
 			item := dataVal.Index(i)
 			switch item.Type().Kind() {
 			case reflect.Struct:
-				key := item.FieldByName(name)
+				key := fieldByTagOrName(item, name)
 				if key.IsValid() {
 					groupItems(key, item, group)
 				}
 			case reflect.Interface:
 				mapItem := item.Elem()
 				if mapItem.Type().Kind() == reflect.Struct {
-					itemKey := mapItem.FieldByName(name)
+					itemKey := fieldByTagOrName(mapItem, name)
 					groupItems(itemKey, mapItem, group)
 				}
 
@@ -378,6 +384,184 @@ func SumFunc() api.UnFunc {
 	})
 }
 
+// CountFunc generates an api.UnFunc that returns the number of items,
+// as an int, of a batch received as a slice, an array or a map.  When the
+// batch is a tuple.KV (i.e. a group restreamed after a GroupBy operation), the
+// function returns a tuple.KV with the key and the number of items of the value,
+// giving the count per group.  Other types are returned as a StreamError.
+func CountFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		if kv, ok := param0.(tuple.KV); ok {
+			count, ok := lenOf(kv[1])
+			if !ok {
+				return api.Error(fmt.Sprintf("Count expects a batch as group value, got %T", kv[1]))
+			}
+			return tuple.KV{kv[0], count}
+		}
+
+		count, ok := lenOf(param0)
+		if !ok {
+			return api.Error(fmt.Sprintf("Count expects a batch, got %T", param0))
+		}
+		return count
+	})
+}
+
+// lenOf returns the length of a slice, an array or a map
+func lenOf(data interface{}) (int, bool) {
+	dataVal := reflect.ValueOf(data)
+	if !dataVal.IsValid() {
+		return 0, false
+	}
+	switch dataVal.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return dataVal.Len(), true
+	}
+	return 0, false
+}
+
+// AvgFunc generates an api.UnFunc that computes the arithmetic mean of
+// numeric items batched from upstream.  As with SumFunc, the data is expected
+// to be of the following types:
+//  []integers
+//  []floats
+//  [][]integers
+//  [][]floats
+// Non-numeric items are ignored.  The function returns the mean as a float64
+// or a StreamError when the batch has no numeric items.
+func AvgFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataVal := reflect.ValueOf(param0)
+		if !dataVal.IsValid() || (dataVal.Kind() != reflect.Slice && dataVal.Kind() != reflect.Array) {
+			return api.Error(fmt.Sprintf("Average expects a batch of numeric values, got %T", param0))
+		}
+
+		var sum float64
+		count := 0
+		add := func(item reflect.Value) {
+			if item.Kind() == reflect.Interface {
+				item = item.Elem()
+			}
+			if item.IsValid() && util.IsNumericValue(item) {
+				sum += numAsFloat(item)
+				count++
+			}
+		}
+
+		for i := 0; i < dataVal.Len(); i++ {
+			item := dataVal.Index(i)
+			if item.Kind() == reflect.Interface {
+				item = item.Elem()
+			}
+			if !item.IsValid() {
+				continue
+			}
+			switch item.Kind() {
+			case reflect.Slice, reflect.Array:
+				for j := 0; j < item.Len(); j++ {
+					add(item.Index(j))
+				}
+			default:
+				add(item)
+			}
+		}
+
+		if count == 0 {
+			return api.Error("Average of an empty batch")
+		}
+		return sum / float64(count)
+	})
+}
+
+// MinFunc generates an api.UnFunc that returns the smallest value of
+// numeric items batched from upstream as []T, where T is an integer or a
+// floating point type.  The value is returned with its original type.
+// A StreamError is returned for an empty batch or when the batch contains
+// non-numeric items or items of different types.
+func MinFunc() api.UnFunc {
+	return extremeFunc("Min", func(item, current reflect.Value) bool {
+		return isNumLess(item, current)
+	})
+}
+
+// MaxFunc generates an api.UnFunc that returns the largest value of
+// numeric items batched from upstream as []T, where T is an integer or a
+// floating point type.  The value is returned with its original type.
+// A StreamError is returned for an empty batch or when the batch contains
+// non-numeric items or items of different types.
+func MaxFunc() api.UnFunc {
+	return extremeFunc("Max", func(item, current reflect.Value) bool {
+		return isNumLess(current, item)
+	})
+}
+
+// extremeFunc returns the item of the batch for which better
+// returns true when compared to all other items.
+func extremeFunc(name string, better func(item, current reflect.Value) bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataVal := reflect.ValueOf(param0)
+		if !dataVal.IsValid() || (dataVal.Kind() != reflect.Slice && dataVal.Kind() != reflect.Array) {
+			return api.Error(fmt.Sprintf("%s expects a batch of numeric values, got %T", name, param0))
+		}
+		if dataVal.Len() == 0 {
+			return api.Error(fmt.Sprintf("%s of an empty batch", name))
+		}
+
+		var result reflect.Value
+		for i := 0; i < dataVal.Len(); i++ {
+			item := dataVal.Index(i)
+			if item.Kind() == reflect.Interface {
+				item = item.Elem()
+			}
+			if !item.IsValid() || !util.IsNumericValue(item) {
+				return api.Error(fmt.Sprintf("%s expects numeric values, got %v", name, typeName(item)))
+			}
+			if result.IsValid() && item.Type() != result.Type() {
+				return api.Error(fmt.Sprintf("%s expects values of one type, got %s and %s", name, result.Type(), item.Type()))
+			}
+			if !result.IsValid() || better(item, result) {
+				result = item
+			}
+		}
+		return result.Interface()
+	})
+}
+
+// isNumLess returns true if numeric value a is less than b,
+// both values are expected to be of the same type.
+func isNumLess(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	}
+	return false
+}
+
+// numAsFloat returns numeric value val as a float64
+func numAsFloat(val reflect.Value) float64 {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint())
+	case reflect.Float32, reflect.Float64:
+		return val.Float()
+	}
+	return 0
+}
+
+// typeName returns the type name of val, or nil for invalid values
+func typeName(val reflect.Value) string {
+	if !val.IsValid() {
+		return "nil"
+	}
+	return val.Type().String()
+}
+
 // SortFunc generates an api.UnFunc that sorts batched data from upstream.
 // The batched items are expected to be in the following type:
 //   []T - where T is comparable type (string, numeric, etc)
@@ -416,6 +600,17 @@ func SortFunc() api.UnFunc {
 //   - Use package sort and a Less function to compare v[i][pos] and v[i+1][pos]
 // The function returns the sorted slice
 func SortByPosFunc(pos int) api.UnFunc {
+	return sortByPos(pos, false)
+}
+
+// SortByPosDescFunc is similar to SortByPosFunc but sorts the batched
+// data in descending order of the values at position pos.
+func SortByPosDescFunc(pos int) api.UnFunc {
+	return sortByPos(pos, true)
+}
+
+// sortByPos sorts [][]T by position pos, in descending order if desc is set
+func sortByPos(pos int, desc bool) api.UnFunc {
 	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
@@ -439,6 +634,9 @@ func SortByPosFunc(pos int) api.UnFunc {
 				itemI := rowI.Index(pos)
 				itemJ := rowJ.Index(pos)
 
+				if desc {
+					return util.IsLess(itemJ, itemI)
+				}
 				return util.IsLess(itemI, itemJ)
 			}
 			return false
@@ -490,6 +688,17 @@ func SortByNameFunc(name string) api.UnFunc {
 //   []map[K]V - where K is a comparable type
 // The function returns sorted []map[K]
 func SortByKeyFunc(key interface{}) api.UnFunc {
+	return sortByKey(key, false)
+}
+
+// SortByKeyDescFunc is similar to SortByKeyFunc but sorts the batched
+// maps in descending order of the values at key.
+func SortByKeyDescFunc(key interface{}) api.UnFunc {
+	return sortByKey(key, true)
+}
+
+// sortByKey sorts []map[K]V by key, in descending order if desc is set
+func sortByKey(key interface{}, desc bool) api.UnFunc {
 	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
@@ -510,6 +719,9 @@ func SortByKeyFunc(key interface{}) api.UnFunc {
 			if typeIOk && typeJOk {
 				valI := itemI.MapIndex(reflect.ValueOf(key))
 				valJ := itemJ.MapIndex(reflect.ValueOf(key))
+				if desc {
+					return util.IsLess(valJ, valI)
+				}
 				return util.IsLess(valI, valJ)
 			}
 
@@ -519,6 +731,30 @@ func SortByKeyFunc(key interface{}) api.UnFunc {
 	})
 }
 
+// SortByFunc generates an api.UnFunc operation that sorts batched items
+// from upstream using the provided less function which reports whether
+// item a must sort before item b.  The batched data is expected to be of form:
+//  []T - where T is any Go type (i.e. structs sorted by arbitrary fields)
+//
+// The sort is stable, items that are equal (neither is less than the other)
+// keep their batch order.  The function returns the sorted []T.
+func SortByFunc(less func(a, b interface{}) bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if !dataVal.IsValid() || dataVal.Kind() != reflect.Slice {
+			return param0 // ignores the data
+		}
+
+		sort.SliceStable(param0, func(i, j int) bool {
+			return less(dataVal.Index(i).Interface(), dataVal.Index(j).Interface())
+		})
+
+		return param0
+	})
+}
+
 // SortWithFunc generates an api.UnFunc operation that is intended to sort batched items
 // from upstream using the provided Less function to be used with the sort package.
 //
@@ -545,6 +781,191 @@ func SortWithFunc(f func(batch interface{}, i, j int) bool) api.UnFunc {
 	})
 }
 
+// FrequencyFunc generates an api.UnFunc that counts the occurrences of each
+// distinct value in batched items from upstream.  The batched data is expected
+// to be of type:
+//   []T - where T is comparable, when key == nil
+//   []map[K]V - where V, for K=key, is comparable
+//   []T - where T is a struct with field name key of comparable type
+// The function returns a value of type
+//   map[interface{}]int
+// Since the result is a map, the order of its entries is undefined.  Items
+// that are missing the key, or whose value is not comparable, are not counted.
+func FrequencyFunc(key interface{}) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array {
+			return param0 // ignores the data
+		}
+
+		result := make(map[interface{}]int)
+		for i := 0; i < dataVal.Len(); i++ {
+			val := frequencyValue(dataVal.Index(i), key)
+			if val.IsValid() && val.Kind() == reflect.Interface {
+				val = val.Elem() // check the dynamic value
+			}
+			if val.IsValid() && val.Type().Comparable() {
+				result[val.Interface()]++
+			}
+		}
+		return result
+	})
+}
+
+// SortedFrequencyFunc generates an api.UnFunc that works the same way as
+// FrequencyFunc but returns the frequency table as a value of type
+//   []tuple.KV - where each tuple.KV{value, count} is an entry of the table
+// The entries are sorted by descending counts.  Entries with the same count
+// are sorted by ascending values, which makes the output deterministic.
+func SortedFrequencyFunc(key interface{}) api.UnFunc {
+	freqFn := FrequencyFunc(key)
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		freqs, ok := freqFn.Apply(ctx, param0).(map[interface{}]int)
+		if !ok {
+			return param0 // ignores the data
+		}
+
+		result := make([]tuple.KV, 0, len(freqs))
+		for val, count := range freqs {
+			result = append(result, tuple.KV{val, count})
+		}
+		sort.Slice(result, func(i, j int) bool {
+			countI, countJ := result[i][1].(int), result[j][1].(int)
+			if countI != countJ {
+				return countI > countJ
+			}
+			valI, valJ := reflect.ValueOf(result[i][0]), reflect.ValueOf(result[j][0])
+			if util.IsLess(valI, valJ) || util.IsLess(valJ, valI) {
+				return util.IsLess(valI, valJ)
+			}
+			return fmt.Sprintf("%T:%v", result[i][0], result[i][0]) < fmt.Sprintf("%T:%v", result[j][0], result[j][0])
+		})
+		return result
+	})
+}
+
+// CountByKeyFunc generates an api.UnFunc that counts batched items, of
+// type []T, by the key returned by keyFn for each item (or the item itself
+// when keyFn is nil).  Items with a nil or uncomparable key are not counted.
+// The function returns a value of type
+//   map[interface{}]int
+// When no items are counted, an empty map is returned if emitEmpty is true,
+// otherwise nil is returned (which is not sent downstream).
+func CountByKeyFunc(keyFn func(interface{}) interface{}, emitEmpty bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array {
+			return param0 // ignores the data
+		}
+
+		result := make(map[interface{}]int)
+		for i := 0; i < dataVal.Len(); i++ {
+			key := dataVal.Index(i).Interface()
+			if keyFn != nil {
+				key = keyFn(key)
+			}
+			if key != nil && reflect.TypeOf(key).Comparable() {
+				result[key]++
+			}
+		}
+
+		if len(result) == 0 && !emitEmpty {
+			return nil
+		}
+		return result
+	})
+}
+
+// AggregateGroupsFunc generates an api.UnFunc that aggregates grouped items,
+// as produced by the GroupBy functions, with function fn.  The grouped data
+// is expected to be of type
+//   []map[G][]T or map[G][]T
+// Function fn is applied to each group, with the group key and its items, and
+// the function returns a value of type
+//   map[interface{}]interface{}
+// which maps each group key to the value returned by fn for that group.  Groups
+// for which fn returns nil are omitted.
+func AggregateGroupsFunc(fn func(group interface{}, items []interface{}) interface{}) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataVal := reflect.ValueOf(param0)
+		if !dataVal.IsValid() {
+			return param0
+		}
+
+		result := make(map[interface{}]interface{})
+		aggregate := func(groups reflect.Value) {
+			if groups.Kind() == reflect.Interface {
+				groups = groups.Elem()
+			}
+			if groups.Kind() != reflect.Map {
+				return
+			}
+			iter := groups.MapRange()
+			for iter.Next() {
+				itemsVal := iter.Value()
+				if itemsVal.Kind() == reflect.Interface {
+					itemsVal = itemsVal.Elem()
+				}
+				if itemsVal.Kind() != reflect.Slice && itemsVal.Kind() != reflect.Array {
+					continue
+				}
+				items := make([]interface{}, itemsVal.Len())
+				for i := range items {
+					items[i] = itemsVal.Index(i).Interface()
+				}
+				key := iter.Key().Interface()
+				if val := fn(key, items); val != nil {
+					result[key] = val
+				}
+			}
+		}
+
+		switch dataVal.Kind() {
+		case reflect.Map:
+			aggregate(dataVal)
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < dataVal.Len(); i++ {
+				aggregate(dataVal.Index(i))
+			}
+		default:
+			return param0 // ignores the data
+		}
+		return result
+	})
+}
+
+// frequencyValue returns the value, from a batched item, counted by FrequencyFunc
+func frequencyValue(item reflect.Value, key interface{}) reflect.Value {
+	if item.Type().Kind() == reflect.Interface {
+		item = item.Elem()
+	}
+	if !item.IsValid() || key == nil {
+		return item
+	}
+
+	switch item.Type().Kind() {
+	case reflect.Map:
+		keyVal := reflect.ValueOf(key)
+		if !keyVal.Type().AssignableTo(item.Type().Key()) {
+			return reflect.Value{}
+		}
+		return item.MapIndex(keyVal)
+	case reflect.Struct:
+		name, ok := key.(string)
+		if !ok {
+			return reflect.Value{}
+		}
+		return item.FieldByName(strings.Title(name)) // avoid unexported field panic
+	}
+	return reflect.Value{}
+}
+
 func ForAll(f func(ctx context.Context, batch interface{}) map[interface{}][]interface{}) api.UnFunc {
 	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
 		return f(ctx, param0)
@@ -572,3 +993,20 @@ func sumAll(item reflect.Value) float64 {
 	}
 	return sum
 }
+
+// fieldByTagOrName returns the exported field of struct value item tagged
+// with automi:"name", or else the field with the (capitalized) name
+func fieldByTagOrName(item reflect.Value, name string) reflect.Value {
+	itemType := item.Type()
+	for i := 0; i < itemType.NumField(); i++ {
+		field := itemType.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("automi"), ",")[0]
+		if tag != "" && tag == name {
+			return item.Field(i)
+		}
+	}
+	return item.FieldByName(strings.Title(name)) // avoid unexported field panic
+}
@@ -2,7 +2,11 @@ package batch
 
 import (
 	"context"
+	"reflect"
 	"testing"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/api/tuple"
 )
 
 func TestBatchFuncs_GroupByPos_WithSlice(t *testing.T) {
@@ -78,6 +82,32 @@ func TestBatchFuncs_GroupByName(t *testing.T) {
 
 }
 
+func TestBatchFuncs_GroupByName_Tag(t *testing.T) {
+	type vehicle struct {
+		Name string
+		Type string `automi:"kind"`
+		Kind string // shadowed by the tag
+	}
+	data := []vehicle{
+		{"Spirit", "plane", "a"},
+		{"BigFoot", "truck", "a"},
+		{"Enola", "plane", "b"},
+	}
+
+	val := GroupByNameFunc("kind").Apply(context.TODO(), data)
+	group := val.([]map[interface{}][]interface{})
+	if len(group[0]["plane"]) != 2 || len(group[0]["truck"]) != 1 {
+		t.Fatal("expecting items grouped by tagged field, got", group[0])
+	}
+
+	// falls back to the field name
+	val = GroupByNameFunc("name").Apply(context.TODO(), data)
+	group = val.([]map[interface{}][]interface{})
+	if len(group[0]) != 3 {
+		t.Fatal("expecting items grouped by field name, got", group[0])
+	}
+}
+
 func TestBatchFuncs_GroupByKey(t *testing.T) {
 	op := GroupByKeyFunc("kind")
 	data := []map[string]string{
@@ -106,6 +136,29 @@ func TestBatchFuncs_GroupByKey(t *testing.T) {
 	}
 }
 
+func TestBatchFuncs_AggregateGroups(t *testing.T) {
+	op := AggregateGroupsFunc(func(group interface{}, items []interface{}) interface{} {
+		if group == "empty" {
+			return nil
+		}
+		return len(items)
+	})
+
+	data := []map[interface{}][]interface{}{
+		{"plane": {"spirit", "enola"}, "boat": {"titanic"}, "empty": {}},
+	}
+	result := op.Apply(context.TODO(), data)
+	expected := map[interface{}]interface{}{"plane": 2, "boat": 1}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatal("unexpected aggregates:", result)
+	}
+
+	result = op.Apply(context.TODO(), map[string][]int{"odd": {1, 3, 5}})
+	if !reflect.DeepEqual(result, map[interface{}]interface{}{"odd": 3}) {
+		t.Fatal("unexpected aggregates:", result)
+	}
+}
+
 func TestBatchFuncs_SumInts(t *testing.T) {
 	op := SumFunc()
 	data := [][]int{
@@ -126,6 +179,111 @@ func TestBatchFuncs_SumInts(t *testing.T) {
 	}
 }
 
+func TestBatchFuncs_Count(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     interface{}
+		expected interface{}
+		fails    bool
+	}{
+		{"slice", []int{10, 70, 20}, 3, false},
+		{"empty slice", []string{}, 0, false},
+		{"array", [2]string{"a", "b"}, 2, false},
+		{"map", map[string]int{"a": 1}, 1, false},
+		{"group", tuple.KV{"a", []interface{}{1, 2}}, tuple.KV{"a", 2}, false},
+		{"invalid group", tuple.KV{"a", 1}, nil, true},
+		{"not a batch", 42, nil, true},
+		{"nil", nil, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := CountFunc().Apply(context.TODO(), test.data)
+			if test.fails {
+				if _, ok := result.(api.StreamError); !ok {
+					t.Fatal("expecting StreamError, got", result)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestBatchFuncs_Avg(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     interface{}
+		expected float64
+		fails    bool
+	}{
+		{"ints", []int{10, 70, 20, 40}, 35, false},
+		{"floats", []float32{1.5, 0.5, 2.5}, 1.5, false},
+		{"nested", [][]int{{1, 2}, {3, 4, 5}}, 3, false},
+		{"interfaces", []interface{}{1, 2.5, "x"}, 1.75, false},
+		{"uints", []uint{2, 4}, 3, false},
+		{"empty batch", []int{}, 0, true},
+		{"non-numeric", []string{"a"}, 0, true},
+		{"not a batch", 42, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := AvgFunc().Apply(context.TODO(), test.data)
+			if test.fails {
+				if _, ok := result.(api.StreamError); !ok {
+					t.Fatal("expecting StreamError, got", result)
+				}
+				return
+			}
+			if result != test.expected {
+				t.Fatalf("expecting %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestBatchFuncs_MinMax(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     interface{}
+		min, max interface{}
+		fails    bool
+	}{
+		{"ints", []int{10, -70, 20}, -70, 20, false},
+		{"int64s", []int64{10, 70, 20}, int64(10), int64(70), false},
+		{"float32s", []float32{1.5, 0.5, 2.5}, float32(0.5), float32(2.5), false},
+		{"float64s", []float64{1.5, 0.5, 2.5}, 0.5, 2.5, false},
+		{"interfaces", []interface{}{3, 1, 2}, 1, 3, false},
+		{"single item", []int{7}, 7, 7, false},
+		{"empty batch", []int{}, nil, nil, true},
+		{"mixed types", []interface{}{1, 2.0}, nil, nil, true},
+		{"non-numeric", []string{"a", "b"}, nil, nil, true},
+		{"not a batch", 42, nil, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			min := MinFunc().Apply(context.TODO(), test.data)
+			max := MaxFunc().Apply(context.TODO(), test.data)
+			if test.fails {
+				if _, ok := min.(api.StreamError); !ok {
+					t.Fatal("expecting StreamError for min, got", min)
+				}
+				if _, ok := max.(api.StreamError); !ok {
+					t.Fatal("expecting StreamError for max, got", max)
+				}
+				return
+			}
+			if min != test.min || max != test.max {
+				t.Fatalf("expecting min %v max %v, got %v %v", test.min, test.max, min, max)
+			}
+		})
+	}
+}
+
 func TestBatchFuncs_SumByPos(t *testing.T) {
 	op := SumByPosFunc(2)
 	data := [][]interface{}{
@@ -261,6 +419,26 @@ func TestBatchFuncs_SortByPos(t *testing.T) {
 	}
 }
 
+func TestBatchFuncs_SortByPosDesc(t *testing.T) {
+	op := SortByPosDescFunc(1)
+	data := [][]interface{}{
+		{"Spirit", 3},
+		{"Voyager", 5},
+		{"BigFoot", 1},
+		{"Enola", 4},
+	}
+	val := op.Apply(context.TODO(), data)
+
+	sorted := val.([][]interface{})
+	var names []interface{}
+	for _, row := range sorted {
+		names = append(names, row[0])
+	}
+	if !reflect.DeepEqual(names, []interface{}{"Voyager", "Enola", "Spirit", "BigFoot"}) {
+		t.Fatal("unexpected sort order for result: ", sorted)
+	}
+}
+
 func TestBatchFuncs_SortByName(t *testing.T) {
 	op := SortByNameFunc("Vehicle")
 	type V struct {
@@ -299,6 +477,48 @@ func TestBatchFuncs_SortByKey(t *testing.T) {
 	}
 }
 
+func TestBatchFuncs_SortByFunc(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	data := []user{{"ana", 30}, {"bob", 25}, {"cid", 30}, {"dee", 25}}
+	op := SortByFunc(func(a, b interface{}) bool {
+		return a.(user).Age < b.(user).Age
+	})
+	result := op.Apply(context.TODO(), data)
+
+	// stable sort keeps arrival order on ties
+	expected := []user{{"bob", 25}, {"dee", 25}, {"ana", 30}, {"cid", 30}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatal("unexpected sort result: ", result)
+	}
+
+	if result := op.Apply(context.TODO(), 42); result != 42 {
+		t.Fatal("expecting non-batch value to be ignored, got ", result)
+	}
+}
+
+func TestBatchFuncs_SortByKeyDesc(t *testing.T) {
+	op := SortByKeyDescFunc("Vehicle")
+	data := []map[string]string{
+		{"Vehicle": "Spirit"},
+		{"Vehicle": "Voyager"},
+		{"Vehicle": "BigFoot"},
+		{"Vehicle": "Enola"},
+	}
+	val := op.Apply(context.TODO(), data)
+
+	sorted := val.([]map[string]string)
+	var names []string
+	for _, item := range sorted {
+		names = append(names, item["Vehicle"])
+	}
+	if !reflect.DeepEqual(names, []string{"Voyager", "Spirit", "Enola", "BigFoot"}) {
+		t.Fatal("unexpected sort order: ", names)
+	}
+}
+
 func TestBatchFuncs_SortWithFunc(t *testing.T) {
 	op := SortWithFunc(func(batch interface{}, i, j int) bool {
 		items := batch.([]string)
@@ -317,3 +537,87 @@ func TestBatchFuncs_SortWithFunc(t *testing.T) {
 		t.Fatal("Unexpected sort order")
 	}
 }
+
+func TestBatchFuncs_Frequency(t *testing.T) {
+	type vote struct{ Name string }
+	tests := []struct {
+		name     string
+		key      interface{}
+		data     interface{}
+		expected map[interface{}]int
+	}{
+		{
+			name:     "values",
+			data:     []string{"a", "b", "a", "c", "a", "b"},
+			expected: map[interface{}]int{"a": 3, "b": 2, "c": 1},
+		},
+		{
+			name: "map key",
+			key:  "color",
+			data: []map[string]string{
+				{"color": "red"}, {"color": "blue"}, {"size": "big"}, {"color": "red"},
+			},
+			expected: map[interface{}]int{"red": 2, "blue": 1},
+		},
+		{
+			name:     "struct field",
+			key:      "name",
+			data:     []interface{}{vote{"x"}, vote{"y"}, vote{"x"}},
+			expected: map[interface{}]int{"x": 2, "y": 1},
+		},
+		{
+			name:     "uncomparable values",
+			data:     []interface{}{1, []int{1}, 1},
+			expected: map[interface{}]int{1: 2},
+		},
+		{
+			name: "uncomparable map values",
+			key:  "tags",
+			data: []map[string]interface{}{
+				{"tags": "a"}, {"tags": []int{1}}, {"tags": "a"},
+			},
+			expected: map[interface{}]int{"a": 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := FrequencyFunc(test.key).Apply(context.TODO(), test.data)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatal("unexpected frequencies:", result)
+			}
+		})
+	}
+}
+
+func TestBatchFuncs_SortedFrequency(t *testing.T) {
+	op := SortedFrequencyFunc(nil)
+	result := op.Apply(context.TODO(), []int{3, 1, 2, 3, 10, 2, 3})
+	expected := []tuple.KV{{3, 3}, {2, 2}, {1, 1}, {10, 1}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatal("unexpected sorted frequencies:", result)
+	}
+}
+
+func TestBatchFuncs_CountByKey(t *testing.T) {
+	keyFn := func(item interface{}) interface{} {
+		if word := item.(string); word != "" {
+			return word
+		}
+		return nil
+	}
+
+	result := CountByKeyFunc(keyFn, false).Apply(context.TODO(), []string{"a", "b", "", "a"})
+	expected := map[interface{}]int{"a": 2, "b": 1}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatal("unexpected counts:", result)
+	}
+
+	if result := CountByKeyFunc(keyFn, false).Apply(context.TODO(), []string{""}); result != nil {
+		t.Fatal("expecting nil for empty counts, got", result)
+	}
+	result = CountByKeyFunc(keyFn, true).Apply(context.TODO(), []string{""})
+	if counts, ok := result.(map[interface{}]int); !ok || len(counts) != 0 {
+		t.Fatal("expecting empty counts, got", result)
+	}
+}
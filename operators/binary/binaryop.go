@@ -15,6 +15,8 @@ type BinaryOperator struct {
 	op          api.BinOperation
 	state       interface{}
 	concurrency int
+	running     bool
+	processed   bool // set when at least one item was received
 	input       <-chan interface{}
 	output      chan interface{}
 	logf        api.LogFunc
@@ -40,6 +42,21 @@ func (o *BinaryOperator) SetInitialState(val interface{}) {
 	o.state = val
 }
 
+// SetRunning sets whether the operator emits its state downstream after
+// each processed item (a running result), instead of only emitting the
+// final state once the input is closed.
+func (o *BinaryOperator) SetRunning(running bool) {
+	o.running = running
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (o *BinaryOperator) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	o.output = make(chan interface{}, bufferSize)
+}
+
 // SetConcurrency sets the concurrency level
 func (o *BinaryOperator) SetConcurrency(concurr int) {
 	o.concurrency = concurr
@@ -71,7 +88,10 @@ func (o *BinaryOperator) Exec(ctx context.Context) (err error) {
 
 	go func() {
 		defer func() {
-			o.output <- o.state
+			// avoid emitting a nil state on empty input
+			if !o.running && (o.processed || o.state != nil) {
+				o.output <- o.state
+			}
 			close(o.output)
 			util.Logfn(o.logf, "Binary operator done")
 		}()
@@ -101,6 +121,7 @@ func (o *BinaryOperator) doOp(ctx context.Context) {
 				return
 			}
 
+			o.processed = true
 			o.state = o.op.Apply(exeCtx, o.state, item)
 
 			switch val := o.state.(type) {
@@ -112,6 +133,14 @@ func (o *BinaryOperator) doOp(ctx context.Context) {
 				continue
 			}
 
+			if o.running {
+				select {
+				case o.output <- o.state:
+				case <-exeCtx.Done():
+					return
+				}
+			}
+
 		// is cancelling
 		case <-exeCtx.Done():
 			return
@@ -2,6 +2,7 @@ package binary
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -89,6 +90,70 @@ func TestBinaryOp_Exec(t *testing.T) {
 	}
 }
 
+func TestBinaryOp_Exec_Running(t *testing.T) {
+	o := New()
+	o.SetInitialState(0)
+	o.SetRunning(true)
+	o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) interface{} {
+		return op1.(int) + op2.(int)
+	}))
+
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		in <- 3
+		close(in)
+	}()
+	o.SetInput(in)
+
+	if err := o.Exec(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []int
+	for out := range o.GetOutput() {
+		result = append(result, out.(int))
+	}
+	if len(result) != 3 || result[0] != 1 || result[1] != 3 || result[2] != 6 {
+		t.Fatal("unexpected running results:", result)
+	}
+}
+
+func TestBinaryOp_Exec_EmptyInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    interface{}
+		expected []interface{}
+	}{
+		{"nil initial state", nil, nil},
+		{"with initial state", 0, []interface{}{0}},
+	}
+
+	for _, test := range tests {
+		o := New()
+		o.SetInitialState(test.state)
+		o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) interface{} {
+			return op2
+		}))
+		in := make(chan interface{})
+		close(in)
+		o.SetInput(in)
+
+		if err := o.Exec(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+
+		var result []interface{}
+		for out := range o.GetOutput() {
+			result = append(result, out)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Fatalf("%s: unexpected result %v", test.name, result)
+		}
+	}
+}
+
 func BenchmarkBinaryOp_Exec(b *testing.B) {
 	ctx := context.Background()
 	o := New()
@@ -39,6 +39,17 @@ func ReduceFunc(f interface{}) (api.BinFunc, error) {
 
 }
 
+// AggregateFunc returns a binary function which uses the user-defined function
+// add to accumulate incoming streamed items into a mutable accumulator.
+// The accumulator (i.e. a map, a pointer to a struct, etc) is updated in place
+// by add and is returned as the partial result for the next item.
+func AggregateFunc(add func(acc, item interface{})) api.BinFunc {
+	return api.BinFunc(func(ctx context.Context, acc, item interface{}) interface{} {
+		add(acc, item)
+		return acc
+	})
+}
+
 func isBinaryFuncForm(ftype reflect.Type) error {
 	// enforce ftype with sig fn(op1,op2)out
 	switch ftype.Kind() {
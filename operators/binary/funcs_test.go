@@ -25,3 +25,20 @@ func TestBinaryFunc_Reduce(t *testing.T) {
 		t.Fatal("unexpected result from ReduceFunc: ", seed)
 	}
 }
+
+func TestBinaryFunc_Aggregate(t *testing.T) {
+	op := AggregateFunc(func(acc, item interface{}) {
+		acc.(map[string]int)[item.(string)]++
+	})
+
+	var acc interface{} = make(map[string]int)
+	ctx := context.TODO()
+	for _, v := range []string{"a", "b", "a"} {
+		acc = op.Apply(ctx, acc, v)
+	}
+
+	counts := acc.(map[string]int)
+	if counts["a"] != 2 || counts["b"] != 1 {
+		t.Fatal("unexpected result from AggregateFunc: ", counts)
+	}
+}
@@ -27,6 +27,14 @@ func New() *StreamOperator {
 	return r
 }
 
+// SetBufferSize sets the buffer size of the output channel
+func (r *StreamOperator) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	r.output = make(chan interface{}, bufferSize)
+}
+
 // SetInput sets the input channel for the executor node
 func (r *StreamOperator) SetInput(in <-chan interface{}) {
 	r.input = in
@@ -0,0 +1,94 @@
+package unary
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taiyang-li/automi/util"
+)
+
+// adaptInterval is the interval at which the input backlog is
+// observed to scale the number of workers.
+var adaptInterval = 10 * time.Millisecond
+
+// SetAdaptiveConcurrency makes the operator adjust its number of concurrent
+// workers between min and max, based on the backlog of its input channel.
+// The operator starts with min workers then, at regular intervals, adds a
+// worker when the input buffer is at least 3/4 full and removes a worker when
+// the input buffer is empty.  An unbuffered input channel never scales up.
+// As with SetConcurrency, items may be emitted out of order.
+func (o *UnaryOperator) SetAdaptiveConcurrency(min, max int) {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	o.minWorkers = min
+	o.maxWorkers = max
+}
+
+// runAdaptive runs and scales the workers of the operator until all
+// of them are done.
+func (o *UnaryOperator) runAdaptive(ctx context.Context) {
+	var wg sync.WaitGroup
+	var active int32
+	var idleOnce sync.Once
+	idle := make(chan struct{}) // closed when all workers are done
+	stop := make(chan struct{})
+
+	start := func() {
+		wg.Add(1)
+		atomic.AddInt32(&active, 1)
+		go func() {
+			defer wg.Done()
+			o.doOp(ctx, stop)
+			if atomic.AddInt32(&active, -1) == 0 {
+				idleOnce.Do(func() { close(idle) })
+			}
+		}()
+	}
+
+	wg.Add(1) // keep group open while supervising
+	for i := 0; i < o.minWorkers; i++ {
+		start()
+	}
+
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(adaptInterval)
+		defer ticker.Stop()
+
+		highMark := cap(o.input) * 3 / 4
+		if highMark < 1 {
+			highMark = 1
+		}
+		for {
+			select {
+			case <-ticker.C:
+				backlog := len(o.input)
+				workers := int(atomic.LoadInt32(&active))
+				switch {
+				case cap(o.input) > 0 && backlog >= highMark && workers < o.maxWorkers:
+					util.Logfn(o.logf, "Unary operator scaling up")
+					start()
+				case backlog == 0 && workers > o.minWorkers:
+					// only stops a worker that is waiting for items
+					select {
+					case stop <- struct{}{}:
+						util.Logfn(o.logf, "Unary operator scaling down")
+					default:
+					}
+				}
+			case <-idle:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,106 @@
+package unary
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestUnaryOp_Exec_AdaptiveConcurrency(t *testing.T) {
+	in := make(chan interface{}, 100)
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	close(in)
+
+	var running, peak int32
+	o := New()
+	o.SetInput(in)
+	o.SetAdaptiveConcurrency(1, 4)
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return data
+	}))
+
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case _, opened := <-o.GetOutput():
+			if !opened {
+				done = true
+				continue
+			}
+			count++
+		case <-timeout:
+			t.Fatal("Took too long")
+		}
+	}
+
+	if count != 100 {
+		t.Fatal("expecting 100 items, got", count)
+	}
+	if p := atomic.LoadInt32(&peak); p < 2 || p > 4 {
+		t.Fatal("expecting workers to scale between 2 and 4, peak", p)
+	}
+}
+
+func TestUnaryOp_Exec_AdaptiveConcurrency_ScaleDown(t *testing.T) {
+	in := make(chan interface{}, 100)
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+
+	var scaledDown int32
+	ctx := autoctx.WithLogFunc(context.Background(), func(msg interface{}) {
+		if msg == "Unary operator scaling down" {
+			atomic.AddInt32(&scaledDown, 1)
+		}
+	})
+
+	o := New()
+	o.SetInput(in)
+	o.SetAdaptiveConcurrency(1, 4)
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		time.Sleep(time.Millisecond)
+		return data
+	}))
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// drain while input stays open, workers should scale back down
+	for i := 0; i < 100; i++ {
+		<-o.GetOutput()
+	}
+	time.Sleep(10 * adaptInterval)
+	if atomic.LoadInt32(&scaledDown) == 0 {
+		t.Fatal("expecting workers to scale down")
+	}
+	in <- 100
+	close(in)
+
+	count := 0
+	for range o.GetOutput() {
+		count++
+	}
+	if count != 1 {
+		t.Fatal("expecting 1 remaining item, got", count)
+	}
+}
@@ -0,0 +1,150 @@
+package unary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/taiyang-li/automi/api"
+)
+
+// DistinctUntilChangedFieldFunc returns a unary function which suppresses
+// consecutive items whose field name is unchanged from the previous item.
+// Items are expected to be structs (or pointers to structs), in which case
+// the struct field is used, or maps with string keys, in which case the map
+// key is used.  Items missing the field are reported to the error function
+// and are not sent downstream.  The returned function keeps state and must
+// not be applied concurrently.
+func DistinctUntilChangedFieldFunc(name string) api.UnFunc {
+	var prev interface{}
+	started := false
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		val, ok := fieldValue(data, name)
+		if !ok {
+			return reportItemErr(ctx, fmt.Errorf("item missing field %s", name), data)
+		}
+		if started && reflect.DeepEqual(prev, val) {
+			return nil
+		}
+		started = true
+		prev = val
+		return data
+	})
+}
+
+// DistinctWithinFunc returns a unary function which suppresses an item if
+// its key, returned by keyFn, appeared within the last window items (whether
+// these items were suppressed or not).  When keyFn is nil, the item itself
+// is used as key.  The keys of the last window items are kept in a ring buffer
+// along with a count map, which bounds memory to O(window) with O(1) checks.
+// Items with uncomparable keys are reported to the error function.  The returned
+// function keeps state and must not be applied concurrently.
+func DistinctWithinFunc(window int, keyFn func(interface{}) interface{}) (api.UnFunc, error) {
+	if window <= 0 {
+		return nil, errors.New("DistinctWithin window must be greater than zero")
+	}
+	if keyFn == nil {
+		keyFn = func(item interface{}) interface{} { return item }
+	}
+
+	ring := make([]interface{}, window)
+	counts := make(map[interface{}]int)
+	pos, size := 0, 0
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		key := keyFn(data)
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return reportItemErr(ctx, fmt.Errorf("DistinctWithin key of type %T is not comparable", key), data)
+		}
+		seen := counts[key] > 0
+
+		// slide the window
+		if size == window {
+			oldest := ring[pos]
+			if counts[oldest]--; counts[oldest] == 0 {
+				delete(counts, oldest)
+			}
+		} else {
+			size++
+		}
+		ring[pos] = key
+		counts[key]++
+		pos = (pos + 1) % window
+
+		if seen {
+			return nil
+		}
+		return data
+	}), nil
+}
+
+// DistinctFunc returns a unary function which suppresses the items whose key
+// has already been seen.  The key of an item is returned by the user-defined
+// function keyFn which must be of type:
+//   func(T) K or func(context.Context, T) K - where K is a comparable key
+// When keyFn is nil, the item itself is used as key.  Items with uncomparable
+// keys are reported to the error function.  The seen keys are kept in a map,
+// which grows with the number of distinct keys; on unbounded streams with
+// high key cardinality, consider DistinctWithinFunc instead.  The returned
+// function keeps state and must not be applied concurrently.
+func DistinctFunc(keyFn interface{}) (api.UnFunc, error) {
+	var fnval reflect.Value
+	var funcForm unaryFuncForm
+	if keyFn != nil {
+		form, err := isUnaryFuncForm(reflect.TypeOf(keyFn))
+		if err != nil {
+			return nil, err
+		}
+		fnval = reflect.ValueOf(keyFn)
+		funcForm = form
+	}
+
+	seen := make(map[interface{}]struct{})
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		key := data
+		if keyFn != nil {
+			key = callOpFunc(fnval, ctx, data, funcForm).Interface()
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return reportItemErr(ctx, fmt.Errorf("Distinct key of type %T is not comparable", key), data)
+		}
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		return data
+	}), nil
+}
+
+// fieldValue returns the value of the named struct field or
+// string map key of item.
+func fieldValue(item interface{}, name string) (interface{}, bool) {
+	itemVal := reflect.ValueOf(item)
+	if itemVal.Kind() == reflect.Ptr {
+		itemVal = itemVal.Elem()
+	}
+
+	switch itemVal.Kind() {
+	case reflect.Struct:
+		field := itemVal.FieldByName(strings.Title(name)) // avoid unexported field panic
+		if !field.IsValid() {
+			return nil, false
+		}
+		return field.Interface(), true
+	case reflect.Map:
+		keyType := itemVal.Type().Key()
+		if keyType.Kind() != reflect.String {
+			return nil, false
+		}
+		val := itemVal.MapIndex(reflect.ValueOf(name).Convert(keyType))
+		if !val.IsValid() {
+			return nil, false
+		}
+		return val.Interface(), true
+	}
+	return nil, false
+}
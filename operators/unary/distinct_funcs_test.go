@@ -0,0 +1,214 @@
+package unary
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestUnaryFunc_DistinctUntilChangedField(t *testing.T) {
+	type reading struct {
+		Sensor string
+		Value  int
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		input    []interface{}
+		expected []interface{}
+		errCount int
+	}{
+		{
+			name:  "struct field",
+			field: "Sensor",
+			input: []interface{}{
+				reading{"a", 1}, reading{"a", 2}, reading{"b", 3}, reading{"a", 4}, reading{"a", 5},
+			},
+			expected: []interface{}{reading{"a", 1}, reading{"b", 3}, reading{"a", 4}},
+		},
+		{
+			name:  "struct pointer field",
+			field: "sensor",
+			input: []interface{}{
+				&reading{"a", 1}, &reading{"a", 2}, &reading{"b", 3},
+			},
+			expected: []interface{}{&reading{"a", 1}, &reading{"b", 3}},
+		},
+		{
+			name:  "map key",
+			field: "sensor",
+			input: []interface{}{
+				map[string]string{"sensor": "a"}, map[string]string{"sensor": "a"}, map[string]string{"sensor": "b"},
+			},
+			expected: []interface{}{map[string]string{"sensor": "a"}, map[string]string{"sensor": "b"}},
+		},
+		{
+			name:     "missing field",
+			field:    "Location",
+			input:    []interface{}{reading{"a", 1}, map[string]string{"sensor": "a"}},
+			errCount: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errCount := 0
+			ctx := autoctx.WithErrorFunc(context.Background(), func(api.StreamError) {
+				errCount++
+			})
+			op := DistinctUntilChangedFieldFunc(test.field)
+			var result []interface{}
+			for _, item := range test.input {
+				if val := op.Apply(ctx, item); val != nil {
+					result = append(result, val)
+				}
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v, got %v", test.expected, result)
+			}
+			if errCount != test.errCount {
+				t.Fatalf("expecting %d errors, got %d", test.errCount, errCount)
+			}
+		})
+	}
+}
+
+func TestUnaryFunc_DistinctWithin(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   int
+		keyFn    func(interface{}) interface{}
+		input    []interface{}
+		expected []interface{}
+		errCount int
+	}{
+		{
+			name:     "window of 1",
+			window:   1,
+			input:    []interface{}{1, 1, 2, 1, 1},
+			expected: []interface{}{1, 2, 1},
+		},
+		{
+			name:     "window of 3",
+			window:   3,
+			input:    []interface{}{1, 2, 1, 3, 4, 1, 2},
+			expected: []interface{}{1, 2, 3, 4, 2},
+		},
+		{
+			name:   "key func",
+			window: 2,
+			keyFn: func(item interface{}) interface{} {
+				return item.(string)[:1]
+			},
+			input:    []interface{}{"apple", "avocado", "banana", "cherry", "apricot"},
+			expected: []interface{}{"apple", "banana", "cherry", "apricot"},
+		},
+		{
+			name:     "uncomparable key",
+			window:   2,
+			input:    []interface{}{1, []int{1}},
+			expected: []interface{}{1},
+			errCount: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errCount := 0
+			ctx := autoctx.WithErrorFunc(context.Background(), func(api.StreamError) {
+				errCount++
+			})
+			op, err := DistinctWithinFunc(test.window, test.keyFn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result []interface{}
+			for _, item := range test.input {
+				if val := op.Apply(ctx, item); val != nil {
+					result = append(result, val)
+				}
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v got %v", test.expected, result)
+			}
+			if errCount != test.errCount {
+				t.Fatalf("expecting %d errors got %d", test.errCount, errCount)
+			}
+		})
+	}
+
+	if _, err := DistinctWithinFunc(0, nil); err == nil {
+		t.Fatal("expecting error for zero window")
+	}
+}
+
+func TestUnaryFunc_Distinct(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyFn    interface{}
+		input    []interface{}
+		expected []interface{}
+		errCount int
+	}{
+		{
+			name:     "item as key",
+			input:    []interface{}{1, 2, 1, 3, 2, 4},
+			expected: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name:     "key func",
+			keyFn:    func(item string) string { return item[:1] },
+			input:    []interface{}{"apple", "banana", "avocado", "cherry", "blueberry"},
+			expected: []interface{}{"apple", "banana", "cherry"},
+		},
+		{
+			name: "key func with context",
+			keyFn: func(ctx context.Context, item int) bool {
+				return item%2 == 0
+			},
+			input:    []interface{}{1, 3, 2, 4, 5},
+			expected: []interface{}{1, 2},
+		},
+		{
+			name:     "uncomparable key",
+			input:    []interface{}{1, []int{1}, 1},
+			expected: []interface{}{1},
+			errCount: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errCount := 0
+			ctx := autoctx.WithErrorFunc(context.Background(), func(api.StreamError) {
+				errCount++
+			})
+			op, err := DistinctFunc(test.keyFn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result []interface{}
+			for _, item := range test.input {
+				if val := op.Apply(ctx, item); val != nil {
+					result = append(result, val)
+				}
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v got %v", test.expected, result)
+			}
+			if errCount != test.errCount {
+				t.Fatalf("expecting %d errors got %d", test.errCount, errCount)
+			}
+		})
+	}
+}
+
+func TestUnaryFunc_Distinct_InvalidKeyFunc(t *testing.T) {
+	if _, err := DistinctFunc(func(a, b, c int) int { return a }); err == nil {
+		t.Fatal("expecting error for unsupported key func")
+	}
+}
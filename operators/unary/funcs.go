@@ -22,8 +22,12 @@ const (
 //   func(T) R
 //   where T is the type of incoming item
 //   R the type of returned processed item
+// The function may also be of type func(T) (R, bool) (see MapResultFunc).
 func ProcessFunc(f interface{}) (api.UnFunc, error) {
 	fntype := reflect.TypeOf(f)
+	if isOkFunc(fntype) {
+		return MapResultFunc(f)
+	}
 
 	funcForm, err := isUnaryFuncForm(fntype)
 	if err != nil {
@@ -82,6 +86,104 @@ func MapFunc(f interface{}) (api.UnFunc, error) {
 	return ProcessFunc(f)
 }
 
+// MapResultFunc returns a unary function which applies the user-defined function
+// that maps the incoming item to a new value along with a flag, in the manner of
+// Go's comma-ok lookups.  The mapped value is only emitted when the flag is true,
+// otherwise the item is dropped.  The user-defined function must be of type:
+//   func(T) (R, bool) - where T is the incoming item, R the type of the mapped item
+func MapResultFunc(f interface{}) (api.UnFunc, error) {
+	fntype := reflect.TypeOf(f)
+
+	funcForm, err := isUnaryOkFuncForm(fntype)
+	if err != nil {
+		return nil, err
+	}
+
+	fnval := reflect.ValueOf(f)
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		results := callOpFuncResults(fnval, ctx, data, funcForm)
+		if !results[1].Bool() {
+			return nil
+		}
+		return results[0].Interface()
+	}), nil
+}
+
+// MapWhenFunc returns a unary function which applies the user-defined function
+// only to items whose dynamic type matches the type of the prototype value proto.
+// Items of other types are returned unchanged.  Types are matched exactly, so
+// a pointer type (i.e. *T) does not match its value type (T) and vice versa.
+func MapWhenFunc(proto interface{}, f func(interface{}) interface{}) (api.UnFunc, error) {
+	if proto == nil {
+		return nil, fmt.Errorf("MapWhen requires a prototype value")
+	}
+	if f == nil {
+		return nil, fmt.Errorf("MapWhen requires a function")
+	}
+	protoType := reflect.TypeOf(proto)
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		if reflect.TypeOf(data) != protoType {
+			return data
+		}
+		return f(data)
+	}), nil
+}
+
+// CompactFunc returns a unary function that filters out nil items, including
+// typed nils (i.e. a nil pointer, map, or slice stored in an interface), and
+// items with the zero value of their type (i.e. 0, "", or an empty struct).
+func CompactFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		if isNilValue(data) || reflect.ValueOf(data).IsZero() {
+			return nil
+		}
+		return data
+	})
+}
+
+// CompactNilFunc returns a unary function that only filters out nil items,
+// including typed nils. Zero values (i.e. 0 or "") are kept.
+func CompactNilFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		if isNilValue(data) {
+			return nil
+		}
+		return data
+	})
+}
+
+// TapFunc returns a unary function that invokes fn with each item, for side
+// effects such as logging or metrics, then returns the item unchanged.  A panic
+// in fn is recovered and reported to the error function, with the item attached,
+// and the item still continues downstream.  Note that fn receives the item
+// itself, fn must not mutate items passed by reference.
+func TapFunc(fn func(interface{})) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					reportItemErr(ctx, fmt.Errorf("tap function panicked: %v", r), data)
+				}
+			}()
+			fn(data)
+		}()
+		return data
+	})
+}
+
+// isNilValue returns true if data is nil or a typed nil value
+func isNilValue(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	val := reflect.ValueOf(data)
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
+
 // FlatMapFunc returns an unary function which applies a user-defined function which
 // takes incoming comsite items and deconstruct them into individual items which can
 // then be re-streamed.  The type for the user-defined function is:
@@ -113,10 +215,34 @@ func FlatMapFunc(f interface{}) (api.UnFunc, error) {
 // isUnaryFuncForm ensures ftype is of supported function of
 // form func(in) out or func(context, in) out
 func isUnaryFuncForm(ftype reflect.Type) (unaryFuncForm, error) {
+	if ftype == nil || ftype.Kind() != reflect.Func {
+		return unaryFuncUnsupported, fmt.Errorf("unary func must be of type func(T)R or func(context.Context,T)R")
+	}
 	if ftype.NumOut() != 1 {
 		return unaryFuncUnsupported, fmt.Errorf("unary func must return one param")
 	}
+	return unaryFuncInForm(ftype)
+}
+
+// isUnaryOkFuncForm ensures ftype is of supported function of
+// form func(in) (out, bool) or func(context, in) (out, bool)
+func isUnaryOkFuncForm(ftype reflect.Type) (unaryFuncForm, error) {
+	if !isOkFunc(ftype) {
+		return unaryFuncUnsupported, fmt.Errorf("unary func must be of type func(T)(R, bool) or func(context.Context,T)(R, bool)")
+	}
+	return unaryFuncInForm(ftype)
+}
+
+// isOkFunc returns true if ftype is a func returning (R, bool)
+func isOkFunc(ftype reflect.Type) bool {
+	return ftype != nil &&
+		ftype.Kind() == reflect.Func &&
+		ftype.NumOut() == 2 &&
+		ftype.Out(1).Kind() == reflect.Bool
+}
 
+// unaryFuncInForm determines the form of the func parameters
+func unaryFuncInForm(ftype reflect.Type) (unaryFuncForm, error) {
 	switch ftype.Kind() {
 	case reflect.Func:
 		switch ftype.NumIn() {
@@ -136,20 +262,29 @@ func isUnaryFuncForm(ftype reflect.Type) (unaryFuncForm, error) {
 }
 
 func callOpFunc(fnval reflect.Value, ctx context.Context, data interface{}, funcForm unaryFuncForm) reflect.Value {
-	var result reflect.Value
+	results := callOpFuncResults(fnval, ctx, data, funcForm)
+	if len(results) == 0 {
+		return reflect.Value{}
+	}
+	return results[0]
+}
+
+// callOpFuncResults calls the func and returns all of its results
+func callOpFuncResults(fnval reflect.Value, ctx context.Context, data interface{}, funcForm unaryFuncForm) []reflect.Value {
+	var results []reflect.Value
 	switch funcForm {
 	case unaryFuncForm1:
 		arg0 := reflect.ValueOf(data)
-		result = fnval.Call([]reflect.Value{arg0})[0]
+		results = fnval.Call([]reflect.Value{arg0})
 	case unaryFuncForm2:
 		arg0 := reflect.ValueOf(ctx)
 		arg1 := reflect.ValueOf(data)
 		if !arg0.IsValid() {
 			arg0 = reflect.ValueOf(context.Background())
 		}
-		result = fnval.Call([]reflect.Value{arg0, arg1})[0]
+		results = fnval.Call([]reflect.Value{arg0, arg1})
 	}
-	return result
+	return results
 }
 
 func isArgContext(val reflect.Value) bool {
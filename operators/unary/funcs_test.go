@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
 )
 
 type unaryFuncTestCase struct {
@@ -217,6 +218,75 @@ func TestUnaryFunc_Map(t *testing.T) {
 	}
 }
 
+func TestUnaryFunc_MapResult(t *testing.T) {
+	lookup := map[string]int{"one": 1, "two": 2}
+	tests := []unaryFuncTestCase{
+		{
+			name:      "MapResult with function f(in)(out,true)",
+			opBuilder: MapResultFunc,
+			input:     "two",
+			procFunc: func(key string) (int, bool) {
+				val, ok := lookup[key]
+				return val, ok
+			},
+			expected: 2,
+		},
+		{
+			name:      "MapResult with function f(in)(out,false)",
+			opBuilder: MapResultFunc,
+			input:     "three",
+			procFunc: func(key string) (int, bool) {
+				val, ok := lookup[key]
+				return val, ok
+			},
+			expected: nil,
+		},
+		{
+			name:      "MapResult with function f(ctx,in)(out,ok)",
+			opBuilder: MapResultFunc,
+			ctx:       context.Background(),
+			input:     "one",
+			procFunc: func(ctx context.Context, key string) (int, bool) {
+				val, ok := lookup[key]
+				return val, ok
+			},
+			expected: 1,
+		},
+		{
+			name:      "Map with function f(in)(out,ok)",
+			opBuilder: MapFunc,
+			input:     "one",
+			procFunc: func(key string) (int, bool) {
+				val, ok := lookup[key]
+				return val, ok
+			},
+			expected: 1,
+		},
+		{
+			name:      "MapResult with function f(in)out",
+			opBuilder: MapResultFunc,
+			input:     "one",
+			procFunc: func(key string) int {
+				return lookup[key]
+			},
+			funcShouldFail: true,
+		},
+		{
+			name:           "MapResult with non-func",
+			opBuilder:      MapResultFunc,
+			input:          "one",
+			procFunc:       "not a func",
+			funcShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testUnaryFunc(t, test)
+		})
+	}
+}
+
 func TestUnaryFunc_FlatMap(t *testing.T) {
 	tests := []unaryFuncTestCase{
 		{
@@ -275,3 +345,107 @@ func TestUnaryFunc_FlatMap(t *testing.T) {
 		})
 	}
 }
+
+func TestUnaryFunc_MapWhen(t *testing.T) {
+	type event struct{ Name string }
+	upper := func(item interface{}) interface{} {
+		switch val := item.(type) {
+		case string:
+			return strings.ToUpper(val)
+		case event:
+			return event{strings.ToUpper(val.Name)}
+		}
+		return item
+	}
+
+	tests := []struct {
+		name     string
+		proto    interface{}
+		input    interface{}
+		expected interface{}
+	}{
+		{"matching type", "", "hello", "HELLO"},
+		{"other type", "", 42, 42},
+		{"matching struct", event{}, event{"a"}, event{"A"}},
+		{"pointer does not match value", event{}, &event{"a"}, &event{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			op, err := MapWhenFunc(test.proto, upper)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := op.Apply(context.TODO(), test.input)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v got %v", test.expected, result)
+			}
+		})
+	}
+
+	if _, err := MapWhenFunc(nil, upper); err == nil {
+		t.Fatal("expecting error for nil prototype")
+	}
+}
+
+func TestUnaryFunc_Compact(t *testing.T) {
+	type point struct{ X, Y int }
+	var nilPtr *point
+	var nilSlice []int
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		compact    interface{}
+		compactNil interface{}
+	}{
+		{"nil", nil, nil, nil},
+		{"typed nil pointer", nilPtr, nil, nil},
+		{"typed nil slice", nilSlice, nil, nil},
+		{"zero int", 0, nil, 0},
+		{"empty string", "", nil, ""},
+		{"zero struct", point{}, nil, point{}},
+		{"non-zero int", 7, 7, 7},
+		{"non-zero struct", point{1, 2}, point{1, 2}, point{1, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := CompactFunc().Apply(context.TODO(), test.input)
+			if !reflect.DeepEqual(result, test.compact) {
+				t.Fatalf("Compact: expecting %v got %v", test.compact, result)
+			}
+			result = CompactNilFunc().Apply(context.TODO(), test.input)
+			if !reflect.DeepEqual(result, test.compactNil) {
+				t.Fatalf("CompactNil: expecting %v got %v", test.compactNil, result)
+			}
+		})
+	}
+}
+
+func TestUnaryFunc_Tap(t *testing.T) {
+	var seen []interface{}
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.TODO(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	op := TapFunc(func(item interface{}) {
+		if item == "boom" {
+			panic("bad item")
+		}
+		seen = append(seen, item)
+	})
+
+	if result := op.Apply(ctx, "hello"); result != "hello" {
+		t.Fatal("expecting item unchanged, got", result)
+	}
+	if result := op.Apply(ctx, "boom"); result != "boom" {
+		t.Fatal("expecting item unchanged after panic, got", result)
+	}
+	if len(seen) != 1 || seen[0] != "hello" {
+		t.Fatal("unexpected tapped items:", seen)
+	}
+	if len(errs) != 1 || errs[0].Item() == nil || errs[0].Item().Item != "boom" {
+		t.Fatal("expecting panic reported with item, got", errs)
+	}
+}
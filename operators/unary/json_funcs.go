@@ -0,0 +1,79 @@
+package unary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/codec"
+	"github.com/taiyang-li/automi/util"
+)
+
+// ParseJSONFunc returns a unary function which unmarshals incoming JSON
+// items, of type []byte or string, into a new value of the same type as
+// the prototype value proto.  For instance:
+//   ParseJSONFunc(Event{})                 - emits values of type Event
+//   ParseJSONFunc(&Event{})                - emits values of type *Event
+//   ParseJSONFunc(map[string]interface{}{}) - emits map[string]interface{}
+// Items that cannot be unmarshalled are not sent downstream, instead they
+// are reported to the error function with the raw item attached.
+func ParseJSONFunc(proto interface{}) (api.UnFunc, error) {
+	if proto == nil {
+		return nil, errors.New("ParseJSON requires a prototype value")
+	}
+	protoType := reflect.TypeOf(proto)
+	isPtr := protoType.Kind() == reflect.Ptr
+	if isPtr {
+		protoType = protoType.Elem()
+	}
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		var raw []byte
+		switch val := data.(type) {
+		case []byte:
+			raw = val
+		case string:
+			raw = []byte(val)
+		default:
+			return reportItemErr(ctx, fmt.Errorf("ParseJSON unexpected item type %T", data), data)
+		}
+
+		result := reflect.New(protoType)
+		if err := json.Unmarshal(raw, result.Interface()); err != nil {
+			return reportItemErr(ctx, fmt.Errorf("ParseJSON: %s", err), data)
+		}
+		if isPtr {
+			return result.Interface()
+		}
+		return result.Elem().Interface()
+	}), nil
+}
+
+// EncodeFunc returns a unary function which marshals incoming items to
+// []byte using the provided codec (i.e. codec.JSON(), codec.PrettyJSON("  "),
+// or codec.Gob()).  Items that cannot be marshalled are not sent downstream,
+// instead they are reported to the error function with the item attached.
+func EncodeFunc(c codec.Codec) (api.UnFunc, error) {
+	if c == nil {
+		return nil, errors.New("Encode requires a codec")
+	}
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		result, err := c.Marshal(data)
+		if err != nil {
+			return reportItemErr(ctx, fmt.Errorf("Encode: %s", err), data)
+		}
+		return result
+	}), nil
+}
+
+// reportItemErr reports err, with the item that caused it, to the error
+// function in the context without sending the item downstream.
+func reportItemErr(ctx context.Context, err error, item interface{}) interface{} {
+	util.Logfn(autoctx.GetLogFunc(ctx), err)
+	autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(err.Error(), &api.StreamItem{Item: item}))
+	return nil
+}
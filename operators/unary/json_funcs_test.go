@@ -0,0 +1,115 @@
+package unary
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/codec"
+)
+
+func TestUnaryFunc_ParseJSON(t *testing.T) {
+	type event struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	tests := []struct {
+		name      string
+		proto     interface{}
+		input     interface{}
+		expected  interface{}
+		shouldErr bool
+	}{
+		{
+			name:     "struct from []byte",
+			proto:    event{},
+			input:    []byte(`{"name":"click","count":2}`),
+			expected: event{Name: "click", Count: 2},
+		},
+		{
+			name:     "struct pointer from string",
+			proto:    &event{},
+			input:    `{"name":"view","count":1}`,
+			expected: &event{Name: "view", Count: 1},
+		},
+		{
+			name:     "map from string",
+			proto:    map[string]interface{}{},
+			input:    `{"name":"view"}`,
+			expected: map[string]interface{}{"name": "view"},
+		},
+		{
+			name:      "malformed JSON",
+			proto:     event{},
+			input:     `{"name":`,
+			shouldErr: true,
+		},
+		{
+			name:      "unsupported item type",
+			proto:     event{},
+			input:     42,
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var reported *api.StreamError
+			ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+				reported = &err
+			})
+			op, err := ParseJSONFunc(test.proto)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := op.Apply(ctx, test.input)
+			if test.shouldErr {
+				if result != nil || reported == nil {
+					t.Fatal("expecting error to be reported")
+				}
+				if reported.Item().Item != test.input {
+					t.Fatal("raw item not attached to error")
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v got %v", test.expected, result)
+			}
+		})
+	}
+
+	if _, err := ParseJSONFunc(nil); err == nil {
+		t.Fatal("expecting error for nil prototype")
+	}
+}
+
+func TestUnaryFunc_Encode(t *testing.T) {
+	var reported []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		reported = append(reported, err)
+	})
+
+	op, err := EncodeFunc(codec.JSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := op.Apply(ctx, map[string]int{"count": 1})
+	if string(result.([]byte)) != `{"count":1}` {
+		t.Fatal("unexpected encoding:", string(result.([]byte)))
+	}
+
+	// channels cannot be marshalled
+	if result := op.Apply(ctx, make(chan int)); result != nil {
+		t.Fatal("expecting nil result for failed encoding")
+	}
+	if len(reported) != 1 {
+		t.Fatal("expecting encoding error to be reported")
+	}
+
+	if _, err := EncodeFunc(nil); err == nil {
+		t.Fatal("expecting error for nil codec")
+	}
+}
@@ -0,0 +1,123 @@
+package unary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+)
+
+// ParseTimeFunc returns a unary function which parses a timestamp field, of
+// incoming records, into a time.Time value using the provided time layout.
+// The field is selected by index and records are expected to be of types:
+//   []T or [N]T - where index is an int position in the record
+//   map[K]V - where index is a key of type K
+// The field value must be a string (or a []byte).  The function returns a
+// copy of the record with the field replaced by its parsed time.Time value:
+//   []T    - is returned as []interface{}
+//   map[K]V - is returned as map[K]interface{}
+// Field values that fail to parse are reported to the error function with
+// the raw value attached, records missing the field are reported with the
+// record attached.  In both cases nothing is sent downstream.
+func ParseTimeFunc(index interface{}, layout string) (api.UnFunc, error) {
+	if index == nil {
+		return nil, errors.New("MapParseTime requires a field index")
+	}
+	if layout == "" {
+		return nil, errors.New("MapParseTime requires a time layout")
+	}
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		dataVal := reflect.ValueOf(data)
+		if !dataVal.IsValid() {
+			return reportItemErr(ctx, errors.New("MapParseTime unexpected nil item"), data)
+		}
+
+		parse := func(field reflect.Value) (interface{}, bool) {
+			if field.Kind() == reflect.Interface {
+				field = field.Elem()
+			}
+			var raw string
+			switch {
+			case field.Kind() == reflect.String:
+				raw = field.String()
+			case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+				raw = string(field.Bytes())
+			default:
+				reportItemErr(ctx, fmt.Errorf("MapParseTime field %v is not a string", index), data)
+				return nil, false
+			}
+			t, err := time.Parse(layout, raw)
+			if err != nil {
+				reportItemErr(ctx, fmt.Errorf("MapParseTime: %s", err), raw)
+				return nil, false
+			}
+			return t, true
+		}
+
+		switch dataVal.Kind() {
+		case reflect.Slice, reflect.Array:
+			pos, ok := index.(int)
+			if !ok || pos < 0 || pos >= dataVal.Len() {
+				return reportItemErr(ctx, fmt.Errorf("MapParseTime index %v out of range", index), data)
+			}
+			t, ok := parse(dataVal.Index(pos))
+			if !ok {
+				return nil
+			}
+			result := make([]interface{}, dataVal.Len())
+			for i := range result {
+				result[i] = dataVal.Index(i).Interface()
+			}
+			result[pos] = t
+			return result
+
+		case reflect.Map:
+			keyVal := reflect.ValueOf(index)
+			keyType := dataVal.Type().Key()
+			if !keyVal.IsValid() || !keyVal.Type().AssignableTo(keyType) {
+				return reportItemErr(ctx, fmt.Errorf("MapParseTime key %v has wrong type", index), data)
+			}
+			field := dataVal.MapIndex(keyVal)
+			if !field.IsValid() {
+				return reportItemErr(ctx, fmt.Errorf("MapParseTime key %v not found", index), data)
+			}
+			t, ok := parse(field)
+			if !ok {
+				return nil
+			}
+			result := reflect.MakeMapWithSize(
+				reflect.MapOf(keyType, reflect.TypeOf((*interface{})(nil)).Elem()),
+				dataVal.Len(),
+			)
+			iter := dataVal.MapRange()
+			for iter.Next() {
+				result.SetMapIndex(iter.Key(), iter.Value())
+			}
+			result.SetMapIndex(keyVal, reflect.ValueOf(t))
+			return result.Interface()
+		}
+
+		return reportItemErr(ctx, fmt.Errorf("MapParseTime unexpected item type %T", data), data)
+	}), nil
+}
+
+// DropExpiredFunc returns a unary function which drops items whose timestamp,
+// returned by tsFn, is older than ttl relative to the current time.  Expired
+// items are reported to the error function, with the item attached, and are
+// not sent downstream.
+func DropExpiredFunc(tsFn func(interface{}) time.Time, ttl time.Duration) (api.UnFunc, error) {
+	if tsFn == nil {
+		return nil, errors.New("DropExpired requires a timestamp function")
+	}
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		if age := time.Since(tsFn(data)); age > ttl {
+			return reportItemErr(ctx, fmt.Errorf("DropExpired item expired %s ago", age-ttl), data)
+		}
+		return data
+	}), nil
+}
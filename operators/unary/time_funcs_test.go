@@ -0,0 +1,130 @@
+package unary
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestUnaryFunc_ParseTime(t *testing.T) {
+	stamp := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+	raw := stamp.Format(time.RFC3339)
+
+	tests := []struct {
+		name        string
+		index       interface{}
+		input       interface{}
+		expected    interface{}
+		reportedRaw interface{}
+	}{
+		{
+			name:     "slice position",
+			index:    1,
+			input:    []string{"click", raw},
+			expected: []interface{}{"click", stamp},
+		},
+		{
+			name:     "map key",
+			index:    "ts",
+			input:    map[string]string{"event": "click", "ts": raw},
+			expected: map[string]interface{}{"event": "click", "ts": stamp},
+		},
+		{
+			name:     "map with interface values",
+			index:    "ts",
+			input:    map[string]interface{}{"count": 2, "ts": []byte(raw)},
+			expected: map[string]interface{}{"count": 2, "ts": stamp},
+		},
+		{
+			name:        "bad timestamp",
+			index:       0,
+			input:       []string{"yesterday"},
+			reportedRaw: "yesterday",
+		},
+		{
+			name:        "index out of range",
+			index:       3,
+			input:       []string{raw},
+			reportedRaw: []string{raw},
+		},
+		{
+			name:        "missing key",
+			index:       "ts",
+			input:       map[string]string{"event": "click"},
+			reportedRaw: map[string]string{"event": "click"},
+		},
+		{
+			name:        "wrong key type",
+			index:       65, // not converted to key "A"
+			input:       map[string]string{"A": raw},
+			reportedRaw: map[string]string{"A": raw},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var reported *api.StreamError
+			ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+				reported = &err
+			})
+			op, err := ParseTimeFunc(test.index, time.RFC3339)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := op.Apply(ctx, test.input)
+			if test.reportedRaw != nil {
+				if result != nil || reported == nil {
+					t.Fatal("expecting error to be reported")
+				}
+				if !reflect.DeepEqual(reported.Item().Item, test.reportedRaw) {
+					t.Fatal("unexpected item attached to error:", reported.Item().Item)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v got %v", test.expected, result)
+			}
+		})
+	}
+
+	if _, err := ParseTimeFunc(nil, time.RFC3339); err == nil {
+		t.Fatal("expecting error for nil index")
+	}
+	if _, err := ParseTimeFunc(0, ""); err == nil {
+		t.Fatal("expecting error for empty layout")
+	}
+}
+
+func TestUnaryFunc_DropExpired(t *testing.T) {
+	var reported []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		reported = append(reported, err)
+	})
+
+	op, err := DropExpiredFunc(func(item interface{}) time.Time {
+		return item.(time.Time)
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := time.Now().Add(-time.Second)
+	stale := time.Now().Add(-time.Hour)
+	if result := op.Apply(ctx, fresh); result != fresh {
+		t.Fatal("fresh item should pass, got", result)
+	}
+	if result := op.Apply(ctx, stale); result != nil {
+		t.Fatal("stale item should be dropped, got", result)
+	}
+	if len(reported) != 1 || reported[0].Item().Item != stale {
+		t.Fatal("expecting expired item to be reported:", reported)
+	}
+
+	if _, err := DropExpiredFunc(nil, time.Minute); err == nil {
+		t.Fatal("expecting error for nil timestamp func")
+	}
+}
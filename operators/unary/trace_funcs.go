@@ -0,0 +1,77 @@
+package unary
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+// TraceFunc returns a unary function which wraps incoming items in an
+// api.StreamItem with a generated correlation ID stored in its MetaData
+// at key api.TraceIDKey.  Items that are already traced keep their ID.
+// When no ID can be generated, the error is reported to the error function
+// and the item is forwarded untraced.
+func TraceFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		item, ok := data.(api.StreamItem)
+		if !ok {
+			item = api.StreamItem{Item: data}
+		}
+		if item.MetaData[api.TraceIDKey] != "" {
+			return item
+		}
+
+		id, err := newTraceID()
+		if err != nil {
+			autoctx.Err(autoctx.GetErrFunc(ctx), api.WrapError(err, nil))
+			return data
+		}
+
+		meta := make(map[string]string, len(item.MetaData)+1)
+		for k, v := range item.MetaData {
+			meta[k] = v
+		}
+		meta[api.TraceIDKey] = id
+		item.MetaData = meta
+		return item
+	})
+}
+
+// newTraceID generates a random 128-bit ID encoded as a hex string
+func newTraceID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("trace ID: %s", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Untrace unwraps items traced with a correlation ID, or with a source
+// offset (see api.OffsetKey), so that the operation is applied to the item
+// data, with the ID available in the context.  The trace is returned so the
+// result can be wrapped back with Retrace, it is nil for items that are not
+// traced.
+func Untrace(ctx context.Context, item interface{}) (context.Context, interface{}, *api.StreamItem) {
+	traced, ok := item.(api.StreamItem)
+	if !ok || (traced.MetaData[api.TraceIDKey] == "" && traced.MetaData[api.OffsetKey] == "") {
+		return ctx, item, nil
+	}
+	if id := traced.MetaData[api.TraceIDKey]; id != "" {
+		ctx = autoctx.WithTraceID(ctx, id)
+	}
+	return ctx, traced.Item, &traced
+}
+
+// Retrace wraps the result of an operation with the trace of the original item
+func Retrace(traced *api.StreamItem, result interface{}) interface{} {
+	if _, ok := result.(api.StreamItem); ok {
+		return result
+	}
+	item := *traced
+	item.Item = result
+	return item
+}
@@ -0,0 +1,74 @@
+package unary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+)
+
+func TestUnaryFunc_Trace(t *testing.T) {
+	op := TraceFunc()
+
+	first := op.Apply(context.TODO(), 1).(api.StreamItem)
+	second := op.Apply(context.TODO(), 1).(api.StreamItem)
+	if first.Item != 1 || first.MetaData[api.TraceIDKey] == "" {
+		t.Fatal("item not traced:", first)
+	}
+	if first.MetaData[api.TraceIDKey] == second.MetaData[api.TraceIDKey] {
+		t.Fatal("expecting unique trace IDs")
+	}
+
+	again := op.Apply(context.TODO(), first).(api.StreamItem)
+	if again.MetaData[api.TraceIDKey] != first.MetaData[api.TraceIDKey] {
+		t.Fatal("traced item should keep its ID")
+	}
+
+	wrapped := api.StreamItem{Item: 2, MetaData: map[string]string{"src": "a"}}
+	traced := op.Apply(context.TODO(), wrapped).(api.StreamItem)
+	if traced.MetaData["src"] != "a" || traced.MetaData[api.TraceIDKey] == "" {
+		t.Fatal("wrapped item not traced:", traced)
+	}
+	if wrapped.MetaData[api.TraceIDKey] != "" {
+		t.Fatal("original metadata should not be modified")
+	}
+}
+
+func TestUnaryOp_Exec_TracedItems(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- api.StreamItem{Item: 2, MetaData: map[string]string{api.TraceIDKey: "abc"}}
+		in <- 3
+		close(in)
+	}()
+
+	var ids []string
+	o := New()
+	o.SetInput(in)
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		ids = append(ids, autoctx.GetTraceID(ctx))
+		return data.(int) * 10
+	}))
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []interface{}
+	for item := range o.GetOutput() {
+		result = append(result, item)
+	}
+	if len(result) != 2 {
+		t.Fatal("unexpected result:", result)
+	}
+	traced, ok := result[0].(api.StreamItem)
+	if !ok || traced.Item != 20 || traced.MetaData[api.TraceIDKey] != "abc" {
+		t.Fatal("trace not propagated:", result[0])
+	}
+	if result[1] != 30 {
+		t.Fatal("untraced item should not be wrapped:", result[1])
+	}
+	if ids[0] != "abc" || ids[1] != "" {
+		t.Fatal("unexpected trace IDs in context:", ids)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/taiyang-li/automi/api"
 	autoctx "github.com/taiyang-li/automi/api/context"
@@ -22,7 +23,18 @@ func pack(vals ...interface{}) packed {
 type UnaryOperator struct {
 	op          api.UnOperation
 	concurrency int
+	minWorkers  int
+	maxWorkers  int
 	bufferSize  int
+	timeout     time.Duration
+	attempts    int
+	backoff     func(attempt int) time.Duration
+	deadLetter  api.ErrorFunc
+	dropItems   bool
+	latencyFn   func(time.Duration)
+	failFn      func()
+	stallFn     func(time.Duration)
+	stallAfter  time.Duration
 	input       <-chan interface{}
 	output      chan interface{}
 	logf        api.LogFunc
@@ -53,6 +65,7 @@ func (o *UnaryOperator) SetConcurrency(concurr int) {
 	}
 }
 
+// SetBufferSize sets the buffer size of the output channel
 func (o *UnaryOperator) SetBufferSize(bufferSize int) {
 	if bufferSize < 1 {
 		bufferSize = 1
@@ -61,6 +74,58 @@ func (o *UnaryOperator) SetBufferSize(bufferSize int) {
 	o.output = make(chan interface{}, o.bufferSize)
 }
 
+// SetTimeout sets the maximum duration allowed for the operation to process
+// a single item.  When the duration expires, the context passed to the operation
+// is cancelled and a StreamError is reported, the item is not sent downstream.
+// A duration <= 0 means no timeout.
+func (o *UnaryOperator) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// SetRetry sets the number of attempts made to process an item when the
+// operation returns an error (a StreamError or an error value).  Function
+// backoff, if not nil, returns the time to wait before the next attempt.
+// Panic and cancellation stream errors are never retried.
+func (o *UnaryOperator) SetRetry(attempts int, backoff func(attempt int) time.Duration) {
+	o.attempts = attempts
+	o.backoff = backoff
+}
+
+// SetDeadLetter sets a function that receives the items that failed all
+// processing attempts, as a StreamError carrying the last error along with
+// the item.  Dead-lettered items are not sent downstream.
+func (o *UnaryOperator) SetDeadLetter(fn api.ErrorFunc) {
+	o.deadLetter = fn
+}
+
+// SetDropErrorItems sets whether the items attached to the stream errors
+// returned by the operation are dropped instead of being sent downstream
+// (i.e. when they are recovered from a dead-letter sink).
+func (o *UnaryOperator) SetDropErrorItems(drop bool) {
+	o.dropItems = drop
+}
+
+// SetLatencyFunc sets a function that receives the time spent by the
+// operation to process each item.  Items are not timed when fn is nil.
+func (o *UnaryOperator) SetLatencyFunc(fn func(time.Duration)) {
+	o.latencyFn = fn
+}
+
+// SetFailureFunc sets a function that is invoked each time the operation
+// fails to process an item (i.e. it returns an error or panics).
+func (o *UnaryOperator) SetFailureFunc(fn func()) {
+	o.failFn = fn
+}
+
+// SetBackpressureFunc sets a function that is invoked when the operator
+// is blocked, sending an item downstream, for longer than threshold.  While
+// the send remains blocked, fn is invoked again after each threshold period
+// with the total time blocked so far.
+func (o *UnaryOperator) SetBackpressureFunc(threshold time.Duration, fn func(time.Duration)) {
+	o.stallAfter = threshold
+	o.stallFn = fn
+}
+
 // SetInput sets the input channel for the executor node
 func (o *UnaryOperator) SetInput(in <-chan interface{}) {
 	o.input = in
@@ -88,12 +153,17 @@ func (o *UnaryOperator) Exec(ctx context.Context) (err error) {
 			close(o.output)
 		}()
 
+		if o.maxWorkers > 0 {
+			o.runAdaptive(ctx)
+			return
+		}
+
 		wg := sync.WaitGroup{}
 		for i := 0; i < o.concurrency; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				o.doOp(ctx)
+				o.doOp(ctx, nil)
 			}()
 		}
 		wg.Wait()
@@ -101,7 +171,9 @@ func (o *UnaryOperator) Exec(ctx context.Context) (err error) {
 	return nil
 }
 
-func (o *UnaryOperator) doOp(ctx context.Context) {
+// doOp applies the operation to incoming items until the input
+// is closed, the context is done, or a signal is received on stop.
+func (o *UnaryOperator) doOp(ctx context.Context, stop <-chan struct{}) {
 	if o.op == nil {
 		util.Logfn(o.logf, "Unary operator missing operation")
 		return
@@ -121,39 +193,54 @@ func (o *UnaryOperator) doOp(ctx context.Context) {
 				return
 			}
 
-			result := o.op.Apply(exeCtx, item)
+			opCtx, data, traced := Untrace(exeCtx, item)
+			var start time.Time
+			if o.latencyFn != nil {
+				start = time.Now()
+			}
+			result := o.applyWithRetry(opCtx, data)
+			if o.latencyFn != nil {
+				o.latencyFn(time.Since(start))
+			}
 
 			switch val := result.(type) {
 			case nil:
 				continue
 			case api.StreamError:
+				o.notifyFailure()
 				util.Logfn(o.logf, val)
 				autoctx.Err(o.errf, val)
-				if item := val.Item(); item != nil {
-					select {
-					case o.output <- *item:
-					case <-exeCtx.Done():
+				if item := val.Item(); item != nil && !o.dropItems {
+					if !o.send(exeCtx, *item) {
 						return
 					}
 				}
 				continue
+			case recoveredPanic:
+				o.notifyFailure()
+				util.Logfn(o.logf, val)
+				autoctx.Err(o.errf, api.StreamError(val.PanicStreamError))
+				continue
 			case api.PanicStreamError:
+				o.notifyFailure()
 				util.Logfn(o.logf, val)
 				autoctx.Err(o.errf, api.StreamError(val))
 				panic(val)
 			case api.CancelStreamError:
 				util.Logfn(o.logf, val)
-				autoctx.Err(o.errf, api.StreamError(val))
+				autoctx.Err(o.errf, api.WrapError(val, nil))
 				return
 			case error:
+				o.notifyFailure()
 				util.Logfn(o.logf, val)
-				autoctx.Err(o.errf, api.Error(val.Error()))
+				autoctx.Err(o.errf, api.WrapError(val, nil))
 				continue
 
 			default:
-				select {
-				case o.output <- val:
-				case <-exeCtx.Done():
+				if traced != nil {
+					val = Retrace(traced, val)
+				}
+				if !o.send(exeCtx, val) {
 					return
 				}
 			}
@@ -161,6 +248,128 @@ func (o *UnaryOperator) doOp(ctx context.Context) {
 		// is cancelling
 		case <-exeCtx.Done():
 			return
+
+		// is scaling down
+		case <-stop:
+			return
 		}
 	}
 }
+
+// notifyFailure invokes the failure function, if set
+func (o *UnaryOperator) notifyFailure() {
+	if o.failFn != nil {
+		o.failFn()
+	}
+}
+
+// send sends val downstream, reporting stalled sends when a backpressure
+// function is set.  It returns false if the context is done.
+func (o *UnaryOperator) send(ctx context.Context, val interface{}) bool {
+	if o.stallFn == nil || o.stallAfter <= 0 {
+		select {
+		case o.output <- val:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// avoid timing sends that do not block
+	select {
+	case o.output <- val:
+		return true
+	default:
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(o.stallAfter)
+	defer timer.Stop()
+	for {
+		select {
+		case o.output <- val:
+			return true
+		case <-timer.C:
+			o.stallFn(time.Since(start))
+			timer.Reset(o.stallAfter)
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// recoveredPanic is the result of an operation that panicked
+type recoveredPanic struct {
+	api.PanicStreamError
+}
+
+// safeApply applies the operation to item, recovering from a panic in the
+// operation as a recoveredPanic carrying the offending item.
+func (o *UnaryOperator) safeApply(ctx context.Context, item interface{}) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := fmt.Sprintf("unary operation panicked: %v", r)
+			result = recoveredPanic{api.PanickingErrorWithItem(msg, &api.StreamItem{Item: item})}
+		}
+	}()
+	return o.op.Apply(ctx, item)
+}
+
+// apply applies the operation to item, within the operator timeout if set.
+func (o *UnaryOperator) apply(ctx context.Context, item interface{}) interface{} {
+	if o.timeout <= 0 {
+		return o.safeApply(ctx, item)
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	done := make(chan interface{}, 1)
+	go func() {
+		done <- o.safeApply(opCtx, item)
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-opCtx.Done():
+		if ctx.Err() != nil {
+			return nil // stream is cancelling
+		}
+		return api.Error(fmt.Sprintf("unary operation timed out after %s", o.timeout))
+	}
+}
+
+// applyWithRetry applies the operation to item, retrying on failures
+// if so configured, then dead-letters the item if all attempts failed.
+func (o *UnaryOperator) applyWithRetry(ctx context.Context, item interface{}) interface{} {
+	result := o.apply(ctx, item)
+	for attempt := 1; attempt < o.attempts && isRetryable(result); attempt++ {
+		if o.backoff != nil {
+			select {
+			case <-time.After(o.backoff(attempt)):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		result = o.apply(ctx, item)
+	}
+
+	if o.deadLetter != nil && isRetryable(result) {
+		msg := result.(error).Error()
+		o.deadLetter(api.ErrorWithItem(msg, &api.StreamItem{Item: item}))
+		return api.Error(msg) // report error, without item
+	}
+	return result
+}
+
+// isRetryable returns true if result is an error that can be retried
+func isRetryable(result interface{}) bool {
+	switch result.(type) {
+	case recoveredPanic, api.PanicStreamError, api.CancelStreamError:
+		return false
+	case error:
+		return true
+	}
+	return false
+}
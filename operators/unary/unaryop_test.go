@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
 	"github.com/taiyang-li/automi/testutil"
 )
 
@@ -277,6 +278,155 @@ func TestUnaryOp_Exec(t *testing.T) {
 	}
 }
 
+func TestUnaryOp_Exec_Timeout(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 10
+		in <- 500
+		in <- 20
+		close(in)
+	}()
+
+	var m sync.Mutex
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		m.Lock()
+		errs = append(errs, err)
+		m.Unlock()
+	})
+
+	o := New()
+	o.SetInput(in)
+	o.SetTimeout(50 * time.Millisecond)
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		select {
+		case <-time.After(time.Duration(data.(int)) * time.Millisecond):
+			return data
+		case <-ctx.Done():
+			return nil
+		}
+	}))
+
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []interface{}
+	for item := range o.GetOutput() {
+		result = append(result, item)
+	}
+	if len(result) != 2 || result[0] != 10 || result[1] != 20 {
+		t.Fatal("unexpected result:", result)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 1 {
+		t.Fatal("expecting timeout error, got", errs)
+	}
+}
+
+func TestUnaryOp_Exec_Backpressure(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		close(in)
+	}()
+
+	var m sync.Mutex
+	var stalls []time.Duration
+	o := New()
+	o.SetInput(in)
+	o.SetBufferSize(1)
+	o.SetBackpressureFunc(10*time.Millisecond, func(d time.Duration) {
+		m.Lock()
+		stalls = append(stalls, d)
+		m.Unlock()
+	})
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		return data
+	}))
+
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// slow consumer stalls the second send
+	time.Sleep(35 * time.Millisecond)
+	var result []interface{}
+	for item := range o.GetOutput() {
+		result = append(result, item)
+	}
+	if len(result) != 2 {
+		t.Fatal("unexpected result:", result)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if len(stalls) == 0 || len(stalls) > 4 {
+		t.Fatal("unexpected stall reports:", stalls)
+	}
+	for i, d := range stalls {
+		if d < 10*time.Millisecond || (i > 0 && d <= stalls[i-1]) {
+			t.Fatal("unexpected stall durations:", stalls)
+		}
+	}
+}
+
+func TestUnaryOp_Exec_Retry(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		close(in)
+	}()
+
+	var m sync.Mutex
+	calls := make(map[int]int)
+	var dead []api.StreamError
+
+	o := New()
+	o.SetInput(in)
+	o.SetRetry(3, func(int) time.Duration { return time.Millisecond })
+	o.SetDeadLetter(func(err api.StreamError) {
+		m.Lock()
+		dead = append(dead, err)
+		m.Unlock()
+	})
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		m.Lock()
+		defer m.Unlock()
+		i := data.(int)
+		calls[i]++
+		// item 1 succeeds on 2nd attempt, item 2 always fails
+		if i == 1 && calls[i] == 2 {
+			return i * 10
+		}
+		return api.ErrorWithItem("failed", &api.StreamItem{Item: i})
+	}))
+
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []interface{}
+	for item := range o.GetOutput() {
+		result = append(result, item)
+	}
+	if len(result) != 1 || result[0] != 10 {
+		t.Fatal("unexpected result:", result)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if calls[1] != 2 || calls[2] != 3 {
+		t.Fatal("unexpected attempts:", calls)
+	}
+	if len(dead) != 1 || dead[0].Item().Item != 2 || dead[0].Error() != "failed" {
+		t.Fatal("unexpected dead letters:", dead)
+	}
+}
+
 func BenchmarkUnaryOp_Exec(b *testing.B) {
 	o := New()
 	N := b.N
@@ -332,3 +482,51 @@ func BenchmarkUnaryOp_Exec(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestUnaryOp_Exec_Panic(t *testing.T) {
+	for _, timeout := range []time.Duration{0, 50 * time.Millisecond} {
+		in := make(chan interface{})
+		go func() {
+			in <- 1
+			in <- 0
+			in <- 2
+			close(in)
+		}()
+
+		var m sync.Mutex
+		var errs []api.StreamError
+		ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+			m.Lock()
+			errs = append(errs, err)
+			m.Unlock()
+		})
+
+		o := New()
+		o.SetInput(in)
+		o.SetTimeout(timeout)
+		o.SetRetry(3, nil)
+		o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+			return 10 / data.(int)
+		}))
+
+		if err := o.Exec(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		var result []interface{}
+		for item := range o.GetOutput() {
+			result = append(result, item)
+		}
+		if len(result) != 2 || result[0] != 10 || result[1] != 5 {
+			t.Fatal("unexpected result:", result)
+		}
+		m.Lock()
+		if len(errs) != 1 {
+			t.Fatal("expecting panic error, got", errs)
+		}
+		if item := errs[0].Item(); item == nil || item.Item != 0 {
+			t.Fatal("expecting panic error with offending item, got", errs[0])
+		}
+		m.Unlock()
+	}
+}
@@ -0,0 +1,112 @@
+package window
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/operators/binary"
+)
+
+// SlidingAggregateFunc generates an api.UnFunc that maintains a rolling
+// aggregate over the last size items of the stream.  For each incoming item,
+// function add updates the aggregate with the entering item and, once the
+// window is full, function remove updates the aggregate with the item exiting
+// the window.  The current aggregate is returned after each item.
+//
+// The aggregate starts out as nil, so add must handle a nil accumulator
+// for the first item.  Functions add and remove are applied in O(1) per item,
+// regardless of the window size.  The returned function keeps state and must
+// not be applied concurrently.
+func SlidingAggregateFunc(size int, add, remove func(acc, item interface{}) interface{}) api.UnFunc {
+	if size < 1 {
+		size = 1
+	}
+	var acc interface{}
+	ring := make([]interface{}, 0, size)
+	oldest := 0
+
+	return api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		if len(ring) < size {
+			ring = append(ring, item)
+		} else {
+			acc = remove(acc, ring[oldest])
+			ring[oldest] = item
+			oldest = (oldest + 1) % size
+		}
+		acc = add(acc, item)
+		return acc
+	})
+}
+
+// CountFunc generates an api.UnFunc that emits, as []interface{}, the last
+// size items of the stream every slide items (count-based sliding window).
+// The first window is emitted once size items are received, the following
+// windows are emitted after each subsequent slide items.  When slide < size,
+// consecutive windows overlap by size-slide items.  When slide == size, the
+// windows are contiguous (tumbling).  When slide > size, the slide-size items
+// received between windows are not included in any window.  Remaining items
+// that do not complete a slide are not emitted.  At most size items are
+// retained in a ring buffer.  The returned function keeps state and must not
+// be applied concurrently.
+func CountFunc(size, slide int) (api.UnFunc, error) {
+	if size <= 0 || slide <= 0 {
+		return nil, errors.New("window size and slide must be greater than zero")
+	}
+	ring := make([]interface{}, 0, size)
+	next := 0 // position of the next (oldest) item once ring is full
+	var count int64
+
+	return api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		count++
+		if len(ring) < size {
+			ring = append(ring, item)
+		} else {
+			ring[next] = item
+			next = (next + 1) % size
+		}
+
+		if count < int64(size) || (count-int64(size))%int64(slide) != 0 {
+			return nil
+		}
+
+		window := make([]interface{}, size)
+		for i := range window {
+			window[i] = ring[(next+i)%size]
+		}
+		return window
+	}), nil
+}
+
+// ReduceFunc generates an api.UnFunc that expects a batch (a slice) of items,
+// i.e. a time window emitted by a batch operator, and folds its items, in order,
+// with the accumulator function fn starting from initial.  The accumulator is
+// reset to initial for each batch and the reduced value is returned.  Function
+// fn must be of type:
+//   func(S,T) R
+//     where S is the partial result (initially the initial value)
+//     T is an item of the batch
+//     R is the calculated value which becomes partial result for next item
+// Non-slice and empty batches return nil, so nothing is emitted downstream.
+func ReduceFunc(initial, fn interface{}) (api.UnFunc, error) {
+	op, err := binary.ReduceFunc(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		items := reflect.ValueOf(data)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			return nil
+		}
+		if items.Len() == 0 {
+			return nil
+		}
+		acc := initial
+		for i := 0; i < items.Len(); i++ {
+			acc = op.Apply(ctx, acc, items.Index(i).Interface())
+		}
+		return acc
+	}), nil
+}
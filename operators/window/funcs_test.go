@@ -0,0 +1,144 @@
+package window
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWindowFunc_SlidingAggregate(t *testing.T) {
+	add := func(acc, item interface{}) interface{} {
+		if acc == nil {
+			return item.(int)
+		}
+		return acc.(int) + item.(int)
+	}
+	remove := func(acc, item interface{}) interface{} {
+		return acc.(int) - item.(int)
+	}
+
+	tests := []struct {
+		name     string
+		size     int
+		input    []int
+		expected []int
+	}{
+		{"window of 3", 3, []int{1, 2, 3, 4, 5}, []int{1, 3, 6, 9, 12}},
+		{"window of 1", 1, []int{1, 2, 3}, []int{1, 2, 3}},
+		{"window larger than input", 10, []int{1, 2, 3}, []int{1, 3, 6}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			op := SlidingAggregateFunc(test.size, add, remove)
+			var result []int
+			for _, item := range test.input {
+				result = append(result, op.Apply(context.TODO(), item).(int))
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatal("unexpected sliding aggregate:", result)
+			}
+		})
+	}
+}
+
+func TestWindowFunc_Count(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		slide    int
+		input    []interface{}
+		expected []interface{}
+	}{
+		{
+			name:  "overlapping",
+			size:  3,
+			slide: 1,
+			input: []interface{}{1, 2, 3, 4, 5},
+			expected: []interface{}{
+				[]interface{}{1, 2, 3},
+				[]interface{}{2, 3, 4},
+				[]interface{}{3, 4, 5},
+			},
+		},
+		{
+			name:  "tumbling",
+			size:  2,
+			slide: 2,
+			input: []interface{}{1, 2, 3, 4, 5},
+			expected: []interface{}{
+				[]interface{}{1, 2},
+				[]interface{}{3, 4},
+			},
+		},
+		{
+			name:  "gaps",
+			size:  2,
+			slide: 3,
+			input: []interface{}{1, 2, 3, 4, 5, 6, 7, 8},
+			expected: []interface{}{
+				[]interface{}{1, 2},
+				[]interface{}{4, 5},
+				[]interface{}{7, 8},
+			},
+		},
+		{
+			name:     "fewer items than size",
+			size:     4,
+			slide:    1,
+			input:    []interface{}{1, 2, 3},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			op, err := CountFunc(test.size, test.slide)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result []interface{}
+			for _, item := range test.input {
+				if val := op.Apply(context.Background(), item); val != nil {
+					result = append(result, val)
+				}
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestWindowFunc_Count_Invalid(t *testing.T) {
+	if _, err := CountFunc(0, 1); err == nil {
+		t.Fatal("expecting error for zero size")
+	}
+	if _, err := CountFunc(1, 0); err == nil {
+		t.Fatal("expecting error for zero slide")
+	}
+}
+
+func TestWindowFunc_Reduce(t *testing.T) {
+	op, err := ReduceFunc(10, func(acc, item int) int {
+		return acc + item
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// accumulator is reset to the initial value for each window
+	if result := op.Apply(context.TODO(), []int{1, 2, 3}); result != 16 {
+		t.Fatal("unexpected reduction:", result)
+	}
+	if result := op.Apply(context.TODO(), []int{4}); result != 14 {
+		t.Fatal("unexpected reduction:", result)
+	}
+	if result := op.Apply(context.TODO(), []int{}); result != nil {
+		t.Fatal("expecting nil for empty window, got", result)
+	}
+
+	if _, err := ReduceFunc(0, func(item int) int { return item }); err == nil {
+		t.Fatal("expecting error for non-binary function")
+	}
+}
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"time"
+)
+
+// DefaultBackpressureThreshold is the time an operator can be blocked
+// sending an item downstream before the backpressure function is invoked.
+const DefaultBackpressureThreshold = 100 * time.Millisecond
+
+// backpressureNotifier is implemented by operators that can report
+// stalled sends
+type backpressureNotifier interface {
+	SetBackpressureFunc(time.Duration, func(time.Duration))
+}
+
+// OnBackpressure sets a function that is invoked when an operator is blocked
+// sending an item downstream (i.e. its output buffer is full because downstream
+// cannot keep up) for longer than the backpressure threshold (see
+// WithBackpressureThreshold).  The function receives the name of the stalled
+// operator, as set by Named, and the time it has been blocked.  Unnamed
+// operators are identified by their position in the stream (i.e. "op2" for the
+// second operation).  While an operator remains blocked, fn is invoked at most
+// once per threshold period.  Function fn is called from the operator goroutines
+// and must be safe for concurrent use.  Currently, the unary operations (i.e.
+// Map, Filter, Process, etc) report backpressure.
+func (s *Stream) OnBackpressure(fn func(operatorName string, duration time.Duration)) *Stream {
+	s.backpressureFn = fn
+	return s
+}
+
+// WithBackpressureThreshold sets the time an operator can be blocked sending
+// an item downstream before the OnBackpressure function is invoked.  It
+// defaults to DefaultBackpressureThreshold.
+func (s *Stream) WithBackpressureThreshold(d time.Duration) *Stream {
+	s.backpressureAfter = d
+	return s
+}
+
+// setupBackpressure installs the backpressure function on the operators
+func (s *Stream) setupBackpressure() {
+	if s.backpressureFn == nil {
+		return
+	}
+	threshold := s.backpressureAfter
+	if threshold <= 0 {
+		threshold = DefaultBackpressureThreshold
+	}
+
+	for i, op := range s.ops {
+		notifier, ok := op.(backpressureNotifier)
+		if !ok {
+			continue
+		}
+		name := s.opName(i, op)
+		notifier.SetBackpressureFunc(threshold, func(d time.Duration) {
+			s.backpressureFn(name, d)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_OnBackpressure(t *testing.T) {
+	var m sync.Mutex
+	stalled := make(map[string]int)
+
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WithBufferSize(1).
+		WithBackpressureThreshold(5 * time.Millisecond).
+		OnBackpressure(func(name string, d time.Duration) {
+			m.Lock()
+			stalled[name]++
+			m.Unlock()
+		}).
+		Map(func(i int) int { return i }).Named("double").
+		Filter(func(i int) bool { return true }).
+		Into(collectors.Func(func(interface{}) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}))
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if stalled["op2"] == 0 {
+		t.Fatal("expecting stall reports for last operator, got", stalled)
+	}
+	for name := range stalled {
+		if name != "double" && name != "op2" {
+			t.Fatal("unexpected operator name:", name)
+		}
+	}
+}
+
+func TestStream_OnBackpressure_NoStall(t *testing.T) {
+	called := false
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		OnBackpressure(func(string, time.Duration) { called = true }).
+		Map(func(i int) int { return i })
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Fatal("unexpected backpressure report")
+	}
+}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// signalBuffer is an operator that buffers incoming items and
+// flushes them, as a slice, each time its trigger fires.
+type signalBuffer struct {
+	trigger <-chan struct{}
+	input   <-chan interface{}
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// newSignalBuffer creates a *signalBuffer
+func newSignalBuffer(trigger <-chan struct{}) *signalBuffer {
+	return &signalBuffer{
+		trigger: trigger,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (b *signalBuffer) SetInput(in <-chan interface{}) {
+	b.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (b *signalBuffer) GetOutput() <-chan interface{} {
+	return b.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (b *signalBuffer) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	b.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (b *signalBuffer) Exec(ctx context.Context) error {
+	b.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(b.logf, "Signal buffer starting")
+
+	if b.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(b.logf, "Signal buffer closing")
+			close(b.output)
+		}()
+
+		var buffer []interface{}
+		flush := func() bool {
+			if len(buffer) == 0 {
+				return true
+			}
+			select {
+			case b.output <- buffer:
+				buffer = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		trigger := b.trigger
+		for {
+			select {
+			case item, opened := <-b.input:
+				if !opened {
+					flush()
+					return
+				}
+				buffer = append(buffer, item)
+			case _, opened := <-trigger:
+				if !opened {
+					trigger = nil // stop listening, flush at close
+					continue
+				}
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,243 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/operators/unary"
+	"github.com/taiyang-li/automi/util"
+)
+
+// CheckpointStore persists the progress of a stream, that is the offset
+// of the source item from which the stream resumes.
+type CheckpointStore interface {
+	// Save persists the offset from which to resume the source
+	Save(offset int64) error
+	// Load returns the last saved offset, or 0 if none
+	Load() (int64, error)
+}
+
+// ResumableSource is a source that can resume emitting its items from
+// an offset, that is after skipping its first offset items (see
+// emitters.SliceEmitter).
+type ResumableSource interface {
+	api.Source
+	Resume(offset int64)
+}
+
+// Checkpoint saves, in store, the offset from which the source resumes when
+// the stream is restarted (i.e. after a crash).  When the stream is opened,
+// the source resumes from the offset loaded from store, it must therefore be
+// a ResumableSource (i.e. a slice) or Open returns an error.
+//
+// Items carry the offset of their source item down to the sink.  When the sink
+// receives an item, it has written all the items before it, so every n items
+// the offset of the item received is saved; the item itself is processed again
+// when the stream resumes (at-least-once).  When the stream completes without
+// error, the offset of the end of the source is saved.  Offsets are carried by
+// unary operators (Map, Filter, etc) and operators that forward items (i.e.
+// Throttle), and are lost by the other operators (i.e. Batch), after which only
+// the end of the source is saved.  Offsets are saved in the order items reach
+// the sink, which expects operators to keep the order of items (the default,
+// see WithConcurrency).  Errors from store.Save are reported to the error
+// function.
+func (s *Stream) Checkpoint(store CheckpointStore, n int) *Stream {
+	s.checkpoint = newCheckpointer(store, n)
+	return s
+}
+
+// setupCheckpoint resumes the source from the offset loaded from the store,
+// then places an offset operator after the source and the checkpoint
+// operator ahead of the sink
+func (s *Stream) setupCheckpoint() error {
+	if s.checkpoint == nil {
+		return nil
+	}
+	if s.checkpoint.store == nil {
+		return errors.New("Checkpoint store missing")
+	}
+	src, ok := s.source.(ResumableSource)
+	if !ok {
+		return fmt.Errorf("Checkpoint requires a resumable source, got %T", s.source)
+	}
+	offset, err := s.checkpoint.store.Load()
+	if err != nil {
+		return fmt.Errorf("Checkpoint load failed: %s", err)
+	}
+	src.Resume(offset)
+
+	s.checkpoint.offsets = newOffsetter(offset)
+	s.ops = append([]api.Operator{s.checkpoint.offsets}, s.ops...)
+	s.ops = append(s.ops, s.checkpoint)
+	return nil
+}
+
+// offsetter is an operator placed after the stream source to wrap
+// items in an api.StreamItem that carries their source offset.
+type offsetter struct {
+	next   int64 // accessed atomically, offset of the next source item
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newOffsetter creates an *offsetter, starting at offset
+func newOffsetter(offset int64) *offsetter {
+	return &offsetter{
+		next:   offset,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *offsetter) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (o *offsetter) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// offset returns the offset of the next source item
+func (o *offsetter) offset() int64 {
+	return atomic.LoadInt64(&o.next)
+}
+
+// Exec is the execution starting point for the executor node.
+func (o *offsetter) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(o.logf, "Offset operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(o.logf, "Offset operator closing")
+			close(o.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				offset := atomic.AddInt64(&o.next, 1) - 1
+				wrapped := api.StreamItem{
+					Index:    offset,
+					Item:     item,
+					MetaData: map[string]string{api.OffsetKey: strconv.FormatInt(offset, 10)},
+				}
+				select {
+				case o.output <- wrapped:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// checkpointer is an operator placed before the stream sink to unwrap
+// items from their source offset, and save the offset in a CheckpointStore.
+type checkpointer struct {
+	store   CheckpointStore
+	every   int64
+	offsets *offsetter
+	drained bool // input closed before the stream was cancelled
+	input   <-chan interface{}
+	output  chan interface{}
+	logf    api.LogFunc
+	errf    api.ErrorFunc
+}
+
+// newCheckpointer creates a *checkpointer
+func newCheckpointer(store CheckpointStore, every int) *checkpointer {
+	return &checkpointer{
+		store:  store,
+		every:  int64(every),
+		output: make(chan interface{}), // unbuffered, sends complete when sink receives
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (c *checkpointer) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (c *checkpointer) GetOutput() <-chan interface{} {
+	return c.output
+}
+
+// Exec is the execution starting point for the executor node.
+func (c *checkpointer) Exec(ctx context.Context) error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Checkpoint operator starting")
+
+	if c.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Checkpoint operator closing")
+			close(c.output)
+		}()
+
+		var count int64
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					c.drained = ctx.Err() == nil
+					return
+				}
+				_, data, trace := unary.Untrace(ctx, item)
+				select {
+				case c.output <- data:
+				case <-ctx.Done():
+					return
+				}
+				if trace == nil || trace.MetaData[api.OffsetKey] == "" {
+					continue
+				}
+				count++
+				if c.every > 0 && count%c.every == 0 {
+					c.save(trace.Index)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// complete saves the offset of the end of the source, once the sink
+// completed without error after all items were received
+func (c *checkpointer) complete() {
+	if c.drained {
+		c.save(c.offsets.offset())
+	}
+}
+
+// save saves offset in the store, reporting errors
+func (c *checkpointer) save(offset int64) {
+	if err := c.store.Save(offset); err != nil {
+		util.Logfn(c.logf, err)
+		autoctx.Err(c.errf, api.Error(fmt.Sprintf("Checkpoint save failed: %s", err)))
+	}
+}
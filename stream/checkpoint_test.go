@@ -0,0 +1,173 @@
+package stream
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+type testCheckpointStore struct {
+	mutex   sync.Mutex
+	offset  int64
+	saved   []int64
+	saveErr error
+}
+
+func (s *testCheckpointStore) Save(offset int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.offset = offset
+	s.saved = append(s.saved, offset)
+	return nil
+}
+
+func (s *testCheckpointStore) Load() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.offset, nil
+}
+
+func TestStream_Checkpoint(t *testing.T) {
+	store := &testCheckpointStore{}
+	data := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(data)).Filter(func(i int) bool {
+		return i != 4
+	}).Map(func(i int) int {
+		return i * 10
+	}).Checkpoint(store, 3).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{10, 20, 30, 50, 60, 70, 80}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	// offsets of the 3rd and 6th collected items, then the end of the source
+	if !reflect.DeepEqual(store.saved, []int64{2, 6, 8}) {
+		t.Fatal("unexpected checkpoints:", store.saved)
+	}
+}
+
+func TestStream_Checkpoint_Resume(t *testing.T) {
+	store := &testCheckpointStore{offset: 5}
+	data := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	snk := collectors.Slice()
+	strm := New(data).Map(strings.ToUpper).Checkpoint(store, 1).Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"F", "G"}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if !reflect.DeepEqual(store.saved, []int64{5, 6, 7}) {
+		t.Fatal("unexpected checkpoints:", store.saved)
+	}
+}
+
+func TestStream_Checkpoint_Batch(t *testing.T) {
+	store := &testCheckpointStore{}
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).Batch().Sum().Checkpoint(store, 1).Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{float64(10)}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	// offsets are lost through the batch, only the end is saved
+	if !reflect.DeepEqual(store.saved, []int64{4}) {
+		t.Fatal("unexpected checkpoints:", store.saved)
+	}
+}
+
+func TestStream_Checkpoint_Failed(t *testing.T) {
+	store := &testCheckpointStore{}
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WithErrorPolicy(FailOnError).
+		Map(func(i int) interface{} {
+			if i == 3 {
+				return errors.New("bad item")
+			}
+			return i
+		}).Checkpoint(store, 1).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	// the end of the source is not saved for a failed stream
+	for _, offset := range store.saved {
+		if offset > 2 {
+			t.Fatal("unexpected checkpoints:", store.saved)
+		}
+	}
+}
+
+func TestStream_Checkpoint_NotResumable(t *testing.T) {
+	strm := New(emitters.Chan(make(chan int))).Checkpoint(&testCheckpointStore{}, 1).Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for source that cannot resume")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_Checkpoint_SaveError(t *testing.T) {
+	store := &testCheckpointStore{saveErr: errors.New("disk full")}
+	errs := 0
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WithErrorFunc(func(api.StreamError) {
+			errs++
+		}).
+		Checkpoint(store, 2).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if errs != 3 {
+		t.Fatal("expecting 3 save errors, got", errs)
+	}
+}
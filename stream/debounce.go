@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// debouncer is an operator that holds the latest item it received
+// and only sends it downstream once no newer item arrived for a
+// quiet period.  A pending item is flushed when the input closes.
+type debouncer struct {
+	quiet  time.Duration
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newDebouncer creates a *debouncer with quiet period d
+func newDebouncer(d time.Duration) *debouncer {
+	return &debouncer{
+		quiet:  d,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (d *debouncer) SetInput(in <-chan interface{}) {
+	d.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (d *debouncer) GetOutput() <-chan interface{} {
+	return d.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (d *debouncer) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	d.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (d *debouncer) Exec(ctx context.Context) error {
+	d.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(d.logf, "Debounce operator starting")
+
+	if d.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(d.logf, "Debounce operator closing")
+			close(d.output)
+		}()
+
+		timer := time.NewTimer(d.quiet)
+		if !timer.Stop() {
+			<-timer.C
+		}
+		defer timer.Stop()
+
+		var pending interface{}
+		hasPending := false
+		for {
+			select {
+			case item, opened := <-d.input:
+				if !opened {
+					if hasPending {
+						select {
+						case d.output <- pending:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+				// replace pending item and restart quiet period
+				if hasPending && !timer.Stop() {
+					<-timer.C
+				}
+				pending, hasPending = item, true
+				timer.Reset(d.quiet)
+			case <-timer.C:
+				select {
+				case d.output <- pending:
+				case <-ctx.Done():
+					return
+				}
+				pending, hasPending = nil, false
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Debounce collapses bursts of items: an item is only sent downstream once
+// no newer item arrived within duration d, otherwise it is replaced by the
+// newer item (and the quiet period restarts).  When the stream closes, the
+// last pending item is flushed downstream.  This is useful to collapse rapid
+// events, such as file changes, into a single downstream event.
+func (s *Stream) Debounce(d time.Duration) *Stream {
+	if d <= 0 {
+		s.drainErr(errors.New("Debounce requires a positive duration"))
+		return s
+	}
+	return s.appendOp(newDebouncer(d))
+}
@@ -0,0 +1,56 @@
+package stream
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+)
+
+func TestStream_Debounce(t *testing.T) {
+	src := make(chan string)
+	go func() {
+		defer close(src)
+		// first burst
+		for _, item := range []string{"a1", "a2", "a3"} {
+			src <- item
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(30 * time.Millisecond)
+		// second burst, flushed on close
+		for _, item := range []string{"b1", "b2"} {
+			src <- item
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	snk := collectors.Slice()
+	strm := New(src).Debounce(10 * time.Millisecond).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"a3", "b2"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_Debounce_Invalid(t *testing.T) {
+	strm := New([]int{1}).Debounce(0).Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for invalid duration")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// delayer is an operator that waits a fixed duration
+// before sending each item downstream.
+type delayer struct {
+	delay  time.Duration
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newDelayer creates a *delayer with delay d
+func newDelayer(d time.Duration) *delayer {
+	return &delayer{
+		delay:  d,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (d *delayer) SetInput(in <-chan interface{}) {
+	d.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (d *delayer) GetOutput() <-chan interface{} {
+	return d.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (d *delayer) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	d.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (d *delayer) Exec(ctx context.Context) error {
+	d.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(d.logf, "Delay operator starting")
+
+	if d.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(d.logf, "Delay operator closing")
+			close(d.output)
+		}()
+
+		timer := time.NewTimer(d.delay)
+		if !timer.Stop() {
+			<-timer.C
+		}
+		defer timer.Stop()
+
+		for {
+			select {
+			case item, opened := <-d.input:
+				if !opened {
+					return
+				}
+				timer.Reset(d.delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case d.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Delay waits for duration d before sending each item downstream, which
+// adds a fixed latency to every item (i.e. to simulate a slow service in
+// tests or to gently pace a stream).  Unlike Throttle, items are delayed one
+// after the other, regardless of the rate of the stream, so n items take at
+// least n*d.  Pending waits are interrupted when the stream is cancelled.
+func (s *Stream) Delay(d time.Duration) *Stream {
+	if d <= 0 {
+		s.drainErr(errors.New("Delay requires a positive duration"))
+		return s
+	}
+	return s.appendOp(newDelayer(d))
+}
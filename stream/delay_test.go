@@ -0,0 +1,65 @@
+package stream
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_Delay(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c"})).
+		Delay(10 * time.Millisecond).
+		Map(strings.ToUpper).
+		Into(snk)
+
+	start := time.Now()
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"A", "B", "C"}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatal("items not delayed, took", elapsed)
+	}
+}
+
+func TestStream_Delay_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	strm := New(emitters.Slice([]int{1, 2, 3})).WithContext(ctx).
+		Delay(time.Hour).
+		Into(collectors.Null())
+
+	errs := strm.Open()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	select {
+	case <-errs:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Delay did not abort on cancel")
+	}
+}
+
+func TestStream_Delay_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).Delay(0)
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for zero duration")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"context"
+	"sync"
 
 	"github.com/taiyang-li/automi/api"
 	autoctx "github.com/taiyang-li/automi/api/context"
@@ -10,9 +11,10 @@ import (
 
 // Drain is a generic sink that terminates streamed data
 type Drain struct {
-	output chan interface{}
-	input  <-chan interface{}
-	logFn  api.LogFunc
+	output    chan interface{}
+	input     <-chan interface{}
+	logFn     api.LogFunc
+	closeOnce sync.Once
 }
 
 // NewDrain creates a new Drain
@@ -40,12 +42,30 @@ func (s *Drain) Open(ctx context.Context) <-chan error {
 	go func() {
 		defer func() {
 			util.Logfn(s.logFn, "Closing drain")
-			close(s.output)
+			s.closeOutput()
 			close(result)
 		}()
-		for data := range s.input {
-			s.output <- data
+		for {
+			select {
+			case data, opened := <-s.input:
+				if !opened {
+					return
+				}
+				select {
+				case s.output <- data:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	return result
 }
+
+// closeOutput closes the output channel once, it is safe
+// to call even if the drain was never opened.
+func (s *Drain) closeOutput() {
+	s.closeOnce.Do(func() { close(s.output) })
+}
@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// expander is an operator that expands each incoming item into n
+// items by calling its function with indices 0..n-1.
+type expander struct {
+	n      int
+	fn     func(item interface{}, i int) interface{}
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// newExpander creates an *expander
+func newExpander(n int, fn func(interface{}, int) interface{}) *expander {
+	return &expander{
+		n:      n,
+		fn:     fn,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (e *expander) SetInput(in <-chan interface{}) {
+	e.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (e *expander) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (e *expander) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	e.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (e *expander) Exec(ctx context.Context) error {
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Expand operator starting")
+
+	if e.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "Expand operator closing")
+			close(e.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-e.input:
+				if !opened {
+					return
+				}
+				for i := 0; i < e.n; i++ {
+					result := safeApply("MapExpand", item, func() interface{} {
+						return e.fn(item, i)
+					})
+					switch val := result.(type) {
+					case nil:
+						continue
+					case api.StreamError:
+						util.Logfn(e.logf, val)
+						autoctx.Err(e.errf, val)
+						continue
+					case error:
+						util.Logfn(e.logf, val)
+						autoctx.Err(e.errf, api.WrapError(val, nil))
+						continue
+					default:
+						select {
+						case e.output <- val:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
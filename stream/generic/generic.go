@@ -0,0 +1,66 @@
+//go:build go1.18
+
+// Package generic provides type-safe operator functions, using type
+// parameters, for streams.  The functions apply user-defined functions
+// directly to items without reflection, for instance:
+//
+//   strm := stream.New(words)
+//   generic.Map(strm, strings.ToUpper)
+//   generic.Filter(strm, func(s string) bool { return s != "" })
+//
+// Items that are not of the function parameter type are reported to the
+// stream error function and are not sent downstream.
+package generic
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/stream"
+)
+
+// Map applies fn to each item of stream s and sends the result downstream
+func Map[T, R any](s *stream.Stream, fn func(T) R) *stream.Stream {
+	return s.Transform(api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		val, ok := item.(T)
+		if !ok {
+			return typeErr[T]("Map", item)
+		}
+		return fn(val)
+	}))
+}
+
+// Filter sends downstream the items of stream s for which fn returns true
+func Filter[T any](s *stream.Stream, fn func(T) bool) *stream.Stream {
+	return s.Transform(api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		val, ok := item.(T)
+		if !ok {
+			return typeErr[T]("Filter", item)
+		}
+		if !fn(val) {
+			return nil
+		}
+		return item
+	}))
+}
+
+// FlatMap applies fn to each item of stream s, then sends each element of
+// the returned slice downstream individually
+func FlatMap[T, R any](s *stream.Stream, fn func(T) []R) *stream.Stream {
+	s.Transform(api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		val, ok := item.(T)
+		if !ok {
+			return typeErr[T]("FlatMap", item)
+		}
+		return fn(val)
+	}))
+	return s.ReStream()
+}
+
+// typeErr returns a StreamError for an item that is not of type T
+func typeErr[T any](op string, item interface{}) api.StreamError {
+	return api.Error(fmt.Sprintf("generic.%s: item of type %T is not assignable to %s",
+		op, item, reflect.TypeOf((*T)(nil)).Elem()))
+}
@@ -0,0 +1,66 @@
+//go:build go1.18
+
+package generic
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/stream"
+)
+
+func TestGeneric_Operators(t *testing.T) {
+	strm := stream.New([]string{"hello world", "", "happy days"})
+	Filter(strm, func(s string) bool { return s != "" })
+	FlatMap(strm, strings.Fields)
+	Map(strm, func(s string) int { return len(s) })
+
+	snk := collectors.Typed[int]()
+	strm.Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []int{5, 5, 5, 4}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestGeneric_TypeMismatch(t *testing.T) {
+	var errs []string
+	strm := stream.New([]interface{}{"a", 1, "b"}).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err.Error())
+		})
+	Map(strm, strings.ToUpper)
+
+	snk := collectors.Slice()
+	strm.Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"A", "B"}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	expected := []string{"generic.Map: item of type int is not assignable to string"}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Fatal("unexpected errors:", errs)
+	}
+}
@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/api/tuple"
+	"github.com/taiyang-li/automi/operators/unary"
+	"github.com/taiyang-li/automi/util"
+)
+
+// runGrouper is an operator that groups contiguous items with the same
+// key, emitting a tuple.KV{key, []interface{}} each time the key changes
+// and for the last group when the input closes.
+type runGrouper struct {
+	keyOp  api.UnOperation
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// newRunGrouper creates a *runGrouper with key operation keyOp
+func newRunGrouper(keyOp api.UnOperation) *runGrouper {
+	return &runGrouper{
+		keyOp:  keyOp,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (g *runGrouper) SetInput(in <-chan interface{}) {
+	g.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (g *runGrouper) GetOutput() <-chan interface{} {
+	return g.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (g *runGrouper) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	g.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (g *runGrouper) Exec(ctx context.Context) error {
+	g.logf = autoctx.GetLogFunc(ctx)
+	g.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(g.logf, "Group operator starting")
+
+	if g.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(g.logf, "Group operator closing")
+			close(g.output)
+		}()
+
+		var key interface{}
+		var group []interface{}
+		flush := func() bool {
+			if len(group) == 0 {
+				return true
+			}
+			select {
+			case g.output <- tuple.KV{key, group}:
+				group = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case item, opened := <-g.input:
+				if !opened {
+					flush()
+					return
+				}
+				itemKey := safeApply("GroupByFunc key", item, func() interface{} {
+					return g.keyOp.Apply(ctx, item)
+				})
+				switch err := itemKey.(type) {
+				case api.StreamError:
+					util.Logfn(g.logf, err)
+					autoctx.Err(g.errf, err)
+					continue
+				case error:
+					util.Logfn(g.logf, err)
+					autoctx.Err(g.errf, api.WrapError(err, &api.StreamItem{Item: item}))
+					continue
+				}
+				if len(group) > 0 && !reflect.DeepEqual(key, itemKey) {
+					if !flush() {
+						return
+					}
+				}
+				key = itemKey
+				group = append(group, item)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// GroupByFunc groups contiguous items that have the same key, as returned by
+// function keyFn, and emits each group downstream as a tuple.KV{key, []interface{}}
+// without requiring a prior Batch().  Function keyFn must be of type func(T) K or
+// func(context.Context, T) K.  Keys are compared with reflect.DeepEqual.
+//
+// Grouping is done over runs of items: a group is emitted as soon as an item with
+// a different key arrives, and the last group is emitted when the stream closes.
+// Only the current group is buffered, so memory is bounded by the longest run of
+// items with the same key.  Items with the same key that are not contiguous end up
+// in separate groups; sort the stream first, or use GroupInto, to group globally.
+// Items for which keyFn panics, or returns an error, are reported to the error
+// function and skipped.
+func (s *Stream) GroupByFunc(keyFn interface{}) *Stream {
+	op, err := unary.MapFunc(keyFn)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	return s.appendOp(newRunGrouper(op))
+}
@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/api/tuple"
+	"github.com/taiyang-li/automi/collectors"
+)
+
+func TestStream_GroupByFunc(t *testing.T) {
+	type event struct {
+		Device string
+		Value  int
+	}
+	events := []event{
+		{"a", 1}, {"a", 2}, {"b", 3}, {"a", 4}, {"c", 5}, {"c", 6},
+	}
+
+	snk := collectors.Slice()
+	strm := New(events).GroupByFunc(func(e event) string {
+		return e.Device
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{
+		tuple.KV{"a", []interface{}{events[0], events[1]}},
+		tuple.KV{"b", []interface{}{events[2]}},
+		tuple.KV{"a", []interface{}{events[3]}},
+		tuple.KV{"c", []interface{}{events[4], events[5]}},
+	}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected groups:", snk.Get())
+	}
+}
+
+func TestStream_GroupByFunc_Invalid(t *testing.T) {
+	strm := New([]int{1}).GroupByFunc("not a func").Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for invalid key function")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_GroupByFunc_Panic(t *testing.T) {
+	var m sync.Mutex
+	var errs []api.StreamError
+	snk := collectors.Slice()
+	strm := New([]interface{}{1, 1, "x", 2}).
+		WithErrorFunc(func(err api.StreamError) {
+			m.Lock()
+			errs = append(errs, err)
+			m.Unlock()
+		}).
+		GroupByFunc(func(i int) int {
+			return i
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{
+		tuple.KV{1, []interface{}{1, 1}},
+		tuple.KV{2, []interface{}{2}},
+	}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected groups:", snk.Get())
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(errs) != 1 || errs[0].Item() == nil || errs[0].Item().Item != "x" {
+		t.Fatal("expecting error with item x, got", errs)
+	}
+}
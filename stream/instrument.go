@@ -0,0 +1,195 @@
+package stream
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+)
+
+// LatencyStats summarizes the time an operator spent processing items
+type LatencyStats struct {
+	Count int
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// latencyTimer is implemented by operators that can time their processing
+type latencyTimer interface {
+	SetLatencyFunc(func(time.Duration))
+}
+
+// Instrument enables the recording of the time spent by each named operator
+// to process each item.  Operators are named using Named, unnamed operators
+// are not instrumented.  Once the stream completes, the latency percentiles
+// are retrieved with LatencyReport.
+func (s *Stream) Instrument() *Stream {
+	s.instrument = true
+	return s
+}
+
+// Named labels the preceding operation with name, which is used to
+// report its latency stats when the stream is instrumented (see Instrument).
+// Several operations may share the same name, in which case their stats
+// are combined.  The preceding operation must be a unary operation
+// (i.e. Map, Filter, Process, etc).
+func (s *Stream) Named(name string) *Stream {
+	if len(s.ops) == 0 {
+		s.drainErr(fmt.Errorf("Named must follow an operation"))
+		return s
+	}
+	op := s.ops[len(s.ops)-1]
+	if _, ok := op.(latencyTimer); !ok {
+		s.drainErr(fmt.Errorf("Named is not supported for operator %T", op))
+		return s
+	}
+
+	if s.opNames == nil {
+		s.opNames = make(map[api.Operator]string)
+		s.latency = make(map[string]*latencyRecorder)
+	}
+	s.opNames[op] = name
+	if _, ok := s.latency[name]; !ok {
+		s.latency[name] = new(latencyRecorder)
+	}
+	return s
+}
+
+// LatencyReport returns the latency stats, by operator name, recorded
+// when the stream is instrumented (see Instrument).
+func (s *Stream) LatencyReport() map[string]LatencyStats {
+	report := make(map[string]LatencyStats, len(s.latency))
+	for name, rec := range s.latency {
+		report[name] = rec.stats()
+	}
+	return report
+}
+
+// setupInstrumentation installs latency recorders on named operators, when
+// the stream is instrumented, and reports to the metrics of the stream, if set
+func (s *Stream) setupInstrumentation() {
+	if !s.instrument && s.metrics == nil {
+		return
+	}
+	for i, op := range s.ops {
+		timer, ok := op.(latencyTimer)
+		if !ok {
+			continue
+		}
+		var rec *latencyRecorder
+		if name, ok := s.opNames[op]; ok && s.instrument {
+			rec = s.latency[name]
+		}
+		if s.metrics == nil {
+			if rec != nil {
+				timer.SetLatencyFunc(rec.record)
+			}
+			continue
+		}
+
+		name, metrics := s.opName(i, op), s.metrics
+		timer.SetLatencyFunc(func(d time.Duration) {
+			if rec != nil {
+				rec.record(d)
+			}
+			metrics.ItemProcessed(name, d)
+		})
+		if notifier, ok := op.(failureNotifier); ok {
+			notifier.SetFailureFunc(func() {
+				metrics.ItemErrored(name)
+			})
+		}
+	}
+}
+
+// opName returns the name of operator op, at index i, as set by Named or,
+// for unnamed operators, its position in the stream (i.e. "op2")
+func (s *Stream) opName(i int, op api.Operator) string {
+	if name, ok := s.opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("op%d", i+1)
+}
+
+// latencySamples is the maximum number of durations kept by a
+// latencyRecorder to compute percentiles
+const latencySamples = 1024
+
+// latencyRecorder collects processing durations.  The count, min, max and
+// mean are exact while percentiles are computed over a bounded reservoir
+// sample of the durations, which keeps memory constant on unbounded streams.
+type latencyRecorder struct {
+	mutex   sync.Mutex
+	count   int
+	min     time.Duration
+	max     time.Duration
+	total   time.Duration
+	samples []time.Duration
+	rnd     *rand.Rand
+}
+
+func (r *latencyRecorder) record(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.count++
+	r.total += d
+	if r.count == 1 || d < r.min {
+		r.min = d
+	}
+	if d > r.max {
+		r.max = d
+	}
+
+	// reservoir sampling: each duration is kept with
+	// probability latencySamples/count
+	if len(r.samples) < latencySamples {
+		r.samples = append(r.samples, d)
+		return
+	}
+	if r.rnd == nil {
+		r.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if i := r.rnd.Intn(r.count); i < latencySamples {
+		r.samples[i] = d
+	}
+}
+
+func (r *latencyRecorder) stats() LatencyStats {
+	r.mutex.Lock()
+	count, total, min, max := r.count, r.total, r.min, r.max
+	samples := make([]time.Duration, len(r.samples))
+	copy(samples, r.samples)
+	r.mutex.Unlock()
+
+	if count == 0 {
+		return LatencyStats{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	// nearest-rank percentile
+	percentile := func(p float64) time.Duration {
+		rank := int(math.Ceil(p * float64(len(samples))))
+		if rank < 1 {
+			rank = 1
+		}
+		return samples[rank-1]
+	}
+
+	return LatencyStats{
+		Count: count,
+		Min:   min,
+		Max:   max,
+		Mean:  total / time.Duration(count),
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P99:   percentile(0.99),
+	}
+}
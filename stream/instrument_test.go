@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_Instrument(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c", "d"})).
+		Instrument().
+		Map(func(item string) string {
+			time.Sleep(2 * time.Millisecond)
+			return strings.ToUpper(item)
+		}).Named("upper").
+		Filter(func(item string) bool { return item != "B" }).Named("filter").
+		Map(strings.ToLower).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	report := strm.LatencyReport()
+	if len(report) != 2 {
+		t.Fatal("expecting stats for named operators only, got", report)
+	}
+	upper := report["upper"]
+	if upper.Count != 4 {
+		t.Fatal("expecting 4 timed items, got", upper.Count)
+	}
+	if upper.Min < 2*time.Millisecond || upper.P50 < upper.Min || upper.P99 > upper.Max {
+		t.Fatalf("unexpected latency stats: %+v", upper)
+	}
+	if report["filter"].Count != 4 {
+		t.Fatal("expecting 4 timed items, got", report["filter"].Count)
+	}
+}
+
+func TestStream_Instrument_Disabled(t *testing.T) {
+	strm := New(emitters.Slice([]string{"a", "b"})).Map(strings.ToUpper).Named("upper")
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := strm.LatencyReport()["upper"]; stats.Count != 0 {
+		t.Fatal("expecting no stats when not instrumented, got", stats)
+	}
+}
+
+func TestStream_Named_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]string{"a"})).Named("first")
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for Named without operation")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestLatencyRecorder_Stats(t *testing.T) {
+	rec := new(latencyRecorder)
+	if stats := rec.stats(); stats.Count != 0 {
+		t.Fatal("expecting empty stats")
+	}
+	for i := 100; i >= 1; i-- {
+		rec.record(time.Duration(i) * time.Millisecond)
+	}
+	stats := rec.stats()
+	expected := LatencyStats{
+		Count: 100,
+		Min:   time.Millisecond,
+		Max:   100 * time.Millisecond,
+		Mean:  50500 * time.Microsecond,
+		P50:   50 * time.Millisecond,
+		P90:   90 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+	}
+	if stats != expected {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLatencyRecorder_Bounded(t *testing.T) {
+	rec := new(latencyRecorder)
+	for i := 1; i <= 10*latencySamples; i++ {
+		rec.record(time.Duration(i) * time.Microsecond)
+	}
+	if len(rec.samples) != latencySamples {
+		t.Fatal("expecting bounded samples, got", len(rec.samples))
+	}
+
+	stats := rec.stats()
+	if stats.Count != 10*latencySamples || stats.Min != time.Microsecond ||
+		stats.Max != time.Duration(10*latencySamples)*time.Microsecond {
+		t.Fatalf("expecting exact count, min and max: %+v", stats)
+	}
+	if stats.P50 < stats.Min || stats.P90 < stats.P50 || stats.P99 < stats.P90 || stats.P99 > stats.Max {
+		t.Fatalf("unexpected percentiles: %+v", stats)
+	}
+}
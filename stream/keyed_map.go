@@ -0,0 +1,141 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/operators/unary"
+	"github.com/taiyang-li/automi/util"
+)
+
+// keyedMapper is an operator that dispatches items to n workers by the
+// hash of their key, so that items with the same key are processed, in
+// arrival order, by the same worker.
+type keyedMapper struct {
+	n      int
+	keyFn  func(interface{}) interface{}
+	fn     func(interface{}) interface{}
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// newKeyedMapper creates a *keyedMapper
+func newKeyedMapper(n int, keyFn, fn func(interface{}) interface{}) *keyedMapper {
+	return &keyedMapper{
+		n:      n,
+		keyFn:  keyFn,
+		fn:     fn,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (m *keyedMapper) SetInput(in <-chan interface{}) {
+	m.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (m *keyedMapper) GetOutput() <-chan interface{} {
+	return m.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (m *keyedMapper) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	m.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (m *keyedMapper) Exec(ctx context.Context) error {
+	m.logf = autoctx.GetLogFunc(ctx)
+	m.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(m.logf, "Keyed map operator starting")
+
+	if m.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	workers := make([]chan interface{}, m.n)
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = make(chan interface{}, 1024)
+		wg.Add(1)
+		go func(in <-chan interface{}) {
+			defer wg.Done()
+			m.work(ctx, in)
+		}(workers[i])
+	}
+
+	go func() {
+		defer func() {
+			for _, worker := range workers {
+				close(worker)
+			}
+			wg.Wait()
+			util.Logfn(m.logf, "Keyed map operator closing")
+			close(m.output)
+		}()
+
+		seed := autoctx.GetHashSeed(ctx)
+		for {
+			select {
+			case item, opened := <-m.input:
+				if !opened {
+					return
+				}
+				_, data, _ := unary.Untrace(ctx, item)
+				key := safeApply("MapParallelKeyed key", data, func() interface{} {
+					return m.keyFn(data)
+				})
+				if err, ok := key.(api.StreamError); ok {
+					util.Logfn(m.logf, err)
+					autoctx.Err(m.errf, err)
+					continue
+				}
+				worker := workers[util.HashKeySeed(key, seed)%uint64(m.n)]
+				select {
+				case worker <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// work applies the function to the items of a worker input, unwrapping
+// traced items and wrapping their results back with the trace
+func (m *keyedMapper) work(ctx context.Context, in <-chan interface{}) {
+	for item := range in {
+		_, data, traced := unary.Untrace(ctx, item)
+		result := safeApply("MapParallelKeyed", data, func() interface{} {
+			return m.fn(data)
+		})
+		if traced != nil {
+			result = retraceResult(traced, result)
+		}
+		switch val := result.(type) {
+		case nil:
+			continue
+		case api.StreamError:
+			util.Logfn(m.logf, val)
+			autoctx.Err(m.errf, val)
+		default:
+			select {
+			case m.output <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
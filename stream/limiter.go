@@ -0,0 +1,80 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// itemLimiter is an operator placed after the stream source to
+// guard against sources emitting more than max items. When the
+// limit is exceeded, the abort function is invoked with an error.
+type itemLimiter struct {
+	max    int64
+	abort  func(error)
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newItemLimiter creates an *itemLimiter
+func newItemLimiter(max int64, abort func(error)) *itemLimiter {
+	return &itemLimiter{
+		max:    max,
+		abort:  abort,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (l *itemLimiter) SetInput(in <-chan interface{}) {
+	l.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (l *itemLimiter) GetOutput() <-chan interface{} {
+	return l.output
+}
+
+// Exec is the execution starting point for the executor node.
+func (l *itemLimiter) Exec(ctx context.Context) error {
+	l.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(l.logf, "Item limiter starting")
+
+	if l.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(l.logf, "Item limiter closing")
+			close(l.output)
+		}()
+
+		var count int64
+		for {
+			select {
+			case item, opened := <-l.input:
+				if !opened {
+					return
+				}
+				count++
+				if count > l.max {
+					l.abort(fmt.Errorf("stream exceeded max items of %d", l.max))
+					return
+				}
+				select {
+				case l.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
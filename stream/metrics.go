@@ -0,0 +1,34 @@
+package stream
+
+import (
+	"time"
+)
+
+// Metrics receives per-operator measurements from a stream, for instance
+// to update Prometheus counters and histograms.  Operators are identified
+// by their name, as set by Named, or by their position in the stream (i.e.
+// "op2" for the second operation).  Implementations are called from the
+// operator goroutines and must be safe for concurrent use.
+type Metrics interface {
+	// ItemProcessed is invoked after an operator processed an item,
+	// whether it succeeded or not, with the time it took
+	ItemProcessed(op string, d time.Duration)
+
+	// ItemErrored is invoked when an operator failed to process an item
+	ItemErrored(op string)
+}
+
+// failureNotifier is implemented by operators that can report items
+// that failed processing
+type failureNotifier interface {
+	SetFailureFunc(func())
+}
+
+// WithMetrics sets m to receive the processing time of each item, and the
+// processing failures, for each operator of the stream.  When no metrics
+// are set, operators do not time items.  Currently, the unary operations
+// (i.e. Map, Filter, Process, etc) report metrics.
+func (s *Stream) WithMetrics(m Metrics) *Stream {
+	s.metrics = m
+	return s
+}
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+type testMetrics struct {
+	sync.Mutex
+	processed map[string]int
+	errored   map[string]int
+}
+
+func (m *testMetrics) ItemProcessed(op string, d time.Duration) {
+	m.Lock()
+	m.processed[op]++
+	m.Unlock()
+}
+
+func (m *testMetrics) ItemErrored(op string) {
+	m.Lock()
+	m.errored[op]++
+	m.Unlock()
+}
+
+func TestStream_WithMetrics(t *testing.T) {
+	m := &testMetrics{processed: make(map[string]int), errored: make(map[string]int)}
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WithMetrics(m).
+		WithMaxItems(10).
+		Map(func(i int) int { return i * 2 }).
+		Process(func(i int) interface{} {
+			if i > 4 {
+				return api.Error("too big")
+			}
+			return i
+		}).Named("check").
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if m.processed["op1"] != 4 || m.processed["check"] != 4 {
+		t.Fatal("unexpected processed counts:", m.processed)
+	}
+	if len(m.errored) != 1 || m.errored["check"] != 2 {
+		t.Fatal("unexpected error counts:", m.errored)
+	}
+}
+
+func TestStream_WithMetrics_Instrument(t *testing.T) {
+	m := &testMetrics{processed: make(map[string]int), errored: make(map[string]int)}
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		WithMetrics(m).
+		Instrument().
+		Map(func(i int) int { return i }).Named("map").
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if strm.LatencyReport()["map"].Count != 3 {
+		t.Fatal("unexpected latency report:", strm.LatencyReport())
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.processed["map"] != 3 {
+		t.Fatal("unexpected processed counts:", m.processed)
+	}
+}
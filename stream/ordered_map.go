@@ -0,0 +1,206 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/operators/unary"
+	"github.com/taiyang-li/automi/util"
+)
+
+// sequenced is an item (or a result) tagged with its arrival sequence
+type sequenced struct {
+	seq uint64
+	val interface{}
+}
+
+// orderedMapper is an operator that applies its operation to items using
+// n workers, then re-sequences the results in the arrival order of items.
+// The number of items in flight, or waiting in the reorder buffer, is bounded
+// by the window size.
+type orderedMapper struct {
+	n      int
+	window int
+	op     api.UnOperation
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// newOrderedMapper creates an *orderedMapper with n workers
+func newOrderedMapper(n int, op api.UnOperation) *orderedMapper {
+	return &orderedMapper{
+		n:      n,
+		window: 2 * n,
+		op:     op,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (m *orderedMapper) SetInput(in <-chan interface{}) {
+	m.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (m *orderedMapper) GetOutput() <-chan interface{} {
+	return m.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (m *orderedMapper) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	m.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (m *orderedMapper) Exec(ctx context.Context) error {
+	m.logf = autoctx.GetLogFunc(ctx)
+	m.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(m.logf, "Ordered map operator starting")
+
+	if m.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	// a slot is taken for each dispatched item and released when
+	// the item leaves the reorder buffer, bounding memory use
+	slots := make(chan struct{}, m.window)
+	jobs := make(chan sequenced, m.n)
+	results := make(chan sequenced, m.window)
+
+	var wg sync.WaitGroup
+	for i := 0; i < m.n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.work(ctx, jobs, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go m.dispatch(ctx, slots, jobs)
+
+	go func() {
+		defer func() {
+			util.Logfn(m.logf, "Ordered map operator closing")
+			close(m.output)
+		}()
+		m.sequence(ctx, slots, results)
+	}()
+	return nil
+}
+
+// dispatch tags incoming items with a sequence number and sends them to
+// the workers, blocking when the reorder window is full.
+func (m *orderedMapper) dispatch(ctx context.Context, slots chan<- struct{}, jobs chan<- sequenced) {
+	defer close(jobs)
+	var seq uint64
+	for {
+		select {
+		case item, opened := <-m.input:
+			if !opened {
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- sequenced{seq: seq, val: item}:
+				seq++
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// work applies the operation to the dispatched items, unwrapping
+// traced items and wrapping their results back with the trace
+func (m *orderedMapper) work(ctx context.Context, jobs <-chan sequenced, results chan<- sequenced) {
+	for job := range jobs {
+		opCtx, data, traced := unary.Untrace(ctx, job.val)
+		val := safeApply("MapParallel", data, func() interface{} {
+			return m.op.Apply(opCtx, data)
+		})
+		if traced != nil {
+			val = retraceResult(traced, val)
+		}
+		result := sequenced{seq: job.seq, val: val}
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sequence buffers results until they can be emitted in sequence order
+func (m *orderedMapper) sequence(ctx context.Context, slots <-chan struct{}, results <-chan sequenced) {
+	pending := make(map[uint64]interface{})
+	var next uint64
+	for result := range results {
+		pending[result.seq] = result.val
+		for {
+			val, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-slots
+
+			switch v := val.(type) {
+			case nil:
+				continue
+			case api.StreamError:
+				util.Logfn(m.logf, v)
+				autoctx.Err(m.errf, v)
+				continue
+			case error:
+				util.Logfn(m.logf, v)
+				autoctx.Err(m.errf, api.WrapError(v, nil))
+				continue
+			}
+			select {
+			case m.output <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// safeApply invokes apply, recovering from a panic in the user function
+// as a StreamError, of operator name, carrying the offending item.
+func safeApply(name string, item interface{}, apply func() interface{}) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := fmt.Sprintf("%s function panicked: %v", name, r)
+			result = api.ErrorWithItem(msg, &api.StreamItem{Item: item})
+		}
+	}()
+	return apply()
+}
+
+// retraceResult wraps result with trace, unless it is nil or an error
+func retraceResult(trace *api.StreamItem, result interface{}) interface{} {
+	switch result.(type) {
+	case nil, error:
+		return result
+	}
+	return unary.Retrace(trace, result)
+}
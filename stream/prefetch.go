@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// prefetcher is an operator placed after the stream source to eagerly
+// pull up to size items from the source, ahead of downstream operators.
+type prefetcher struct {
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newPrefetcher creates a *prefetcher with a buffer of size items
+func newPrefetcher(size int) *prefetcher {
+	return &prefetcher{
+		output: make(chan interface{}, size),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (p *prefetcher) SetInput(in <-chan interface{}) {
+	p.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (p *prefetcher) GetOutput() <-chan interface{} {
+	return p.output
+}
+
+// Exec is the execution starting point for the executor node.
+func (p *prefetcher) Exec(ctx context.Context) error {
+	p.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(p.logf, "Source prefetcher starting")
+
+	if p.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(p.logf, "Source prefetcher closing")
+			close(p.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-p.input:
+				if !opened {
+					return
+				}
+				select {
+				case p.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,139 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/util"
+)
+
+// BackoffPolicy returns the duration to wait before retry attempt
+// (attempt starts at 1 for the first retry).
+type BackoffPolicy func(attempt int) time.Duration
+
+// ConstantBackoff returns a BackoffPolicy that waits for
+// the same duration d between attempts.
+func ConstantBackoff(d time.Duration) BackoffPolicy {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a BackoffPolicy that waits for initial
+// duration before the first retry, then doubles the wait for each
+// subsequent attempt up to max.
+func ExponentialBackoff(initial, max time.Duration) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		d := initial
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// WithItemRetry makes the immediately preceding operator retry the
+// processing of an item, up to attempts times (including the first attempt),
+// when its function returns an error (a StreamError or an error value).
+// The backoff policy, if not nil, determines the wait between attempts.
+// Waits are interrupted when the stream is cancelled.  Items that fail all
+// attempts are sent to the dead-letter sink dlq, if not nil, as a StreamError
+// with the last error and the original item attached, for instance:
+//
+//   dlq := collectors.Slice()
+//   strm.Map(callService).WithItemRetry(3, stream.ExponentialBackoff(10*time.Millisecond, time.Second), dlq)
+//
+// The dead-letter sink is opened and closed along with the stream.
+func (s *Stream) WithItemRetry(attempts int, backoff BackoffPolicy, dlq api.Sink) *Stream {
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("WithItemRetry must follow an operator"))
+		return s
+	}
+	operator, ok := s.ops[len(s.ops)-1].(interface {
+		SetRetry(int, func(int) time.Duration)
+		SetDeadLetter(api.ErrorFunc)
+	})
+	if !ok {
+		s.drainErr(errors.New("WithItemRetry not supported by preceding operator"))
+		return s
+	}
+
+	operator.SetRetry(attempts, backoff)
+	if dlq != nil {
+		snk := newSideSink(dlq)
+		s.sideSinks = append(s.sideSinks, snk)
+		operator.SetDeadLetter(func(err api.StreamError) {
+			snk.send(s.ctx, err)
+		})
+	}
+	return s
+}
+
+// maxRetryBackoff caps the exponential wait between ProcessRetry attempts
+const maxRetryBackoff = time.Minute
+
+// ProcessRetry applies the user-defined function f, as with Process, and
+// re-invokes f for an item, up to attempts times (including the first attempt),
+// when it returns an error (a StreamError or an error value).  Attempts are
+// separated by an exponential backoff starting at backoff and doubling with
+// each retry (up to one minute).  The error is reported only after the final
+// attempt fails.  Waits are interrupted when the stream is cancelled.
+//
+// ProcessRetry is a shorthand for:
+//
+//   strm.Process(f).WithItemRetry(attempts, stream.ExponentialBackoff(backoff, time.Minute), nil)
+func (s *Stream) ProcessRetry(f interface{}, attempts int, backoff time.Duration) *Stream {
+	var policy BackoffPolicy
+	if backoff > 0 {
+		policy = ExponentialBackoff(backoff, maxRetryBackoff)
+	}
+	return s.Process(f).WithItemRetry(attempts, policy, nil)
+}
+
+// WithStartupProbe sets a probe function that is run when the stream is
+// opened, before any component starts, to verify that the dependencies of the
+// stream are ready (i.e. a database or a broker is reachable).  When the probe
+// returns an error, it is retried up to retries times, waiting between attempts
+// as determined by backoff.  If the probe never succeeds, the stream does not
+// start and Open() returns the last probe error.  Waits are interrupted when
+// the stream context is cancelled.
+func (s *Stream) WithStartupProbe(probe func(ctx context.Context) error, retries int, backoff BackoffPolicy) *Stream {
+	s.probe = &startupProbe{probe: probe, retries: retries, backoff: backoff}
+	return s
+}
+
+// startupProbe runs a probe function, with retries, before a stream starts
+type startupProbe struct {
+	probe   func(ctx context.Context) error
+	retries int
+	backoff BackoffPolicy
+}
+
+// run runs the probe until it succeeds or retries are exhausted
+func (p *startupProbe) run(ctx context.Context, logf api.LogFunc) error {
+	if p.probe == nil {
+		return errors.New("startup probe missing function")
+	}
+	err := p.probe(ctx)
+	for attempt := 1; err != nil && attempt <= p.retries; attempt++ {
+		util.Logfn(logf, fmt.Sprintf("Startup probe failed: %s", err))
+		if p.backoff != nil {
+			select {
+			case <-time.After(p.backoff(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		err = p.probe(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("startup probe failed: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestBackoffPolicies(t *testing.T) {
+	constant := ConstantBackoff(5 * time.Millisecond)
+	if constant(1) != 5*time.Millisecond || constant(10) != 5*time.Millisecond {
+		t.Fatal("unexpected constant backoff")
+	}
+
+	exp := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	expected := []time.Duration{10, 20, 40, 50, 50}
+	for i, d := range expected {
+		if exp(i+1) != d*time.Millisecond {
+			t.Fatalf("attempt %d: expecting %v got %v", i+1, d*time.Millisecond, exp(i+1))
+		}
+	}
+}
+
+func TestStream_WithItemRetry(t *testing.T) {
+	attempts := make(map[int]int)
+	snk := collectors.Slice()
+	dlq := collectors.Slice()
+	errCount := 0
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		WithErrorFunc(func(api.StreamError) {
+			errCount++
+		}).
+		Map(func(i int) interface{} {
+			attempts[i]++
+			if i == 2 {
+				return errors.New("service unavailable")
+			}
+			if attempts[i] < 2 {
+				return api.Error("transient failure")
+			}
+			return i
+		}).WithItemRetry(3, ConstantBackoff(time.Millisecond), dlq).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 2 || snk.Get()[0] != 1 || snk.Get()[1] != 3 {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if attempts[2] != 3 {
+		t.Fatal("expecting 3 attempts, got", attempts[2])
+	}
+	if len(dlq.Get()) != 1 {
+		t.Fatal("unexpected dead letters:", dlq.Get())
+	}
+	dead := dlq.Get()[0].(api.StreamError)
+	if dead.Item().Item != 2 || dead.Error() != "service unavailable" {
+		t.Fatal("unexpected dead letter:", dead)
+	}
+	if errCount != 1 {
+		t.Fatal("expecting exhausted item to be reported, got", errCount)
+	}
+}
+
+func TestStream_WithItemRetry_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	strm := New(emitters.Slice([]int{1})).WithContext(ctx).
+		Map(func(i int) interface{} {
+			return api.Error("failed")
+		}).WithItemRetry(3, ConstantBackoff(time.Hour), nil).
+		Into(collectors.Null())
+
+	errCh := strm.Open()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-errCh:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("backoff not interrupted by cancellation")
+	}
+}
+
+func TestStream_WithStartupProbe(t *testing.T) {
+	probes := 0
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2})).
+		WithStartupProbe(func(ctx context.Context) error {
+			probes++
+			if probes < 3 {
+				return errors.New("broker unavailable")
+			}
+			return nil
+		}, 5, ConstantBackoff(time.Millisecond)).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if probes != 3 || len(snk.Get()) != 2 {
+		t.Fatal("unexpected probes", probes, "or result", snk.Get())
+	}
+}
+
+func TestStream_WithStartupProbe_Failure(t *testing.T) {
+	started := false
+	probes := 0
+	strm := New(emitters.Slice([]int{1, 2})).
+		WithStartupProbe(func(ctx context.Context) error {
+			probes++
+			return errors.New("broker unavailable")
+		}, 2, nil).
+		Map(func(i int) int {
+			started = true
+			return i
+		}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting probe error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if probes != 3 || started {
+		t.Fatal("unexpected probes", probes, "or stream started", started)
+	}
+}
+
+func TestStream_WithStartupProbe_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	strm := New(emitters.Slice([]int{1})).WithContext(ctx).
+		WithStartupProbe(func(ctx context.Context) error {
+			return errors.New("db unavailable")
+		}, 3, ConstantBackoff(time.Hour)).
+		Into(collectors.Null())
+
+	errCh := strm.Open()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expecting cancellation error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("probe backoff not interrupted by cancellation")
+	}
+}
+
+func TestStream_ProcessRetry(t *testing.T) {
+	attempts := make(map[int]int)
+	snk := collectors.Slice()
+	errCount := 0
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		WithErrorFunc(func(api.StreamError) {
+			errCount++
+		}).
+		ProcessRetry(func(i int) interface{} {
+			attempts[i]++
+			if i == 2 {
+				return errors.New("service unavailable")
+			}
+			if attempts[i] < 3 {
+				return api.Error("transient failure")
+			}
+			return i * 10
+		}, 3, time.Millisecond).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 2 || snk.Get()[0] != 10 || snk.Get()[1] != 30 {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if attempts[1] != 3 || attempts[2] != 3 {
+		t.Fatal("unexpected attempts:", attempts)
+	}
+	if errCount != 1 {
+		t.Fatal("expecting only final failure reported, got", errCount)
+	}
+}
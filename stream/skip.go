@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// Skip discards the first n items of the stream and forwards the remaining
+// items downstream (i.e. to skip header or warmup records).  The count of
+// skipped items starts over each time the stream is opened.  If n <= 0,
+// all items are forwarded.
+func (s *Stream) Skip(n int) *Stream {
+	return s.appendOp(newSkipper(n))
+}
+
+// skipper is an operator that discards the first n items
+type skipper struct {
+	n      int
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newSkipper creates a *skipper
+func newSkipper(n int) *skipper {
+	return &skipper{
+		n:      n,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (k *skipper) SetInput(in <-chan interface{}) {
+	k.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (k *skipper) GetOutput() <-chan interface{} {
+	return k.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (k *skipper) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	k.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (k *skipper) Exec(ctx context.Context) error {
+	k.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(k.logf, "Skip operator starting")
+
+	if k.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(k.logf, "Skip operator closing")
+			close(k.output)
+		}()
+
+		skipped := 0
+		for {
+			select {
+			case item, opened := <-k.input:
+				if !opened {
+					return
+				}
+				if skipped < k.n {
+					skipped++
+					continue
+				}
+				select {
+				case k.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
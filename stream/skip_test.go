@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_Skip(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		expected []interface{}
+	}{
+		{"leading items", 2, []interface{}{3, 4, 5}},
+		{"zero items", 0, []interface{}{1, 2, 3, 4, 5}},
+		{"negative", -1, []interface{}{1, 2, 3, 4, 5}},
+		{"more than stream", 10, nil},
+	}
+
+	for _, test := range tests {
+		snk := collectors.Slice()
+		strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).Skip(test.n).Into(snk)
+
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		if !reflect.DeepEqual(snk.Get(), test.expected) {
+			t.Fatalf("%s: unexpected result %v", test.name, snk.Get())
+		}
+	}
+}
+
+func TestSkipper_Reopen(t *testing.T) {
+	op := newSkipper(2)
+	for run := 0; run < 2; run++ {
+		in := make(chan interface{}, 4)
+		for i := 1; i <= 4; i++ {
+			in <- i
+		}
+		close(in)
+
+		op.output = make(chan interface{}, 1024)
+		op.SetInput(in)
+		if err := op.Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		var result []interface{}
+		for item := range op.GetOutput() {
+			result = append(result, item)
+		}
+		if !reflect.DeepEqual(result, []interface{}{3, 4}) {
+			t.Fatalf("run %d: unexpected result %v", run, result)
+		}
+	}
+}
+
+func TestSkipper_Cancel(t *testing.T) {
+	op := newSkipper(100)
+	op.SetInput(make(chan interface{})) // never closed
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := op.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case _, opened := <-op.GetOutput():
+		if opened {
+			t.Fatal("unexpected item")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("skip operator did not exit on cancel")
+	}
+}
@@ -0,0 +1,283 @@
+package stream
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/codec"
+	"github.com/taiyang-li/automi/util"
+)
+
+// externalSorter is an operator that sorts all items of a stream using
+// sorted runs, of at most maxItems, spilled to temporary files which are
+// then merged.  When a run cannot be spilled or merged, the abort function
+// is invoked with an error.
+type externalSorter struct {
+	less     func(a, b interface{}) bool
+	maxItems int
+	codec    codec.Codec
+	abort    func(error)
+	itemType reflect.Type
+	runs     []string
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+// newExternalSorter creates an *externalSorter
+func newExternalSorter(less func(a, b interface{}) bool, maxItems int, c codec.Codec, abort func(error)) *externalSorter {
+	return &externalSorter{
+		less:     less,
+		maxItems: maxItems,
+		codec:    c,
+		abort:    abort,
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (e *externalSorter) SetInput(in <-chan interface{}) {
+	e.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (e *externalSorter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (e *externalSorter) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	e.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (e *externalSorter) Exec(ctx context.Context) error {
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "External sort operator starting")
+
+	if e.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			for _, run := range e.runs {
+				os.Remove(run)
+			}
+			util.Logfn(e.logf, "External sort operator closing")
+			close(e.output)
+		}()
+
+		var buffer []interface{}
+		for {
+			select {
+			case item, opened := <-e.input:
+				if !opened {
+					e.finish(ctx, buffer)
+					return
+				}
+				if item == nil {
+					continue
+				}
+				if e.itemType == nil {
+					e.itemType = reflect.TypeOf(item)
+				}
+				if reflect.TypeOf(item) != e.itemType {
+					e.report(fmt.Errorf("SortExternal expects items of type %s, got %T", e.itemType, item), item)
+					continue
+				}
+				buffer = append(buffer, item)
+				if len(buffer) < e.maxItems {
+					continue
+				}
+				if err := e.spill(buffer); err != nil {
+					e.fail(err)
+					return
+				}
+				buffer = buffer[:0]
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// finish emits the sorted items, from memory if nothing was spilled,
+// otherwise by merging the spilled runs.
+func (e *externalSorter) finish(ctx context.Context, buffer []interface{}) {
+	if len(e.runs) == 0 {
+		sort.SliceStable(buffer, func(i, j int) bool {
+			return e.less(buffer[i], buffer[j])
+		})
+		for _, item := range buffer {
+			select {
+			case e.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+		return
+	}
+
+	if len(buffer) > 0 {
+		if err := e.spill(buffer); err != nil {
+			e.fail(err)
+			return
+		}
+	}
+	if err := e.merge(ctx); err != nil {
+		e.fail(err)
+	}
+}
+
+// spill sorts items and writes them in a temporary run file
+func (e *externalSorter) spill(items []interface{}) error {
+	sort.SliceStable(items, func(i, j int) bool {
+		return e.less(items[i], items[j])
+	})
+
+	file, err := ioutil.TempFile("", "automi-sort-")
+	if err != nil {
+		return fmt.Errorf("SortExternal spill failed: %s", err)
+	}
+	e.runs = append(e.runs, file.Name())
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	var size [binary.MaxVarintLen64]byte
+	for _, item := range items {
+		data, err := e.codec.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("SortExternal spill failed: %s", err)
+		}
+		if err := e.verify(data, item); err != nil {
+			return err
+		}
+		n := binary.PutUvarint(size[:], uint64(len(data)))
+		if _, err := writer.Write(size[:n]); err != nil {
+			return fmt.Errorf("SortExternal spill failed: %s", err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			return fmt.Errorf("SortExternal spill failed: %s", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("SortExternal spill failed: %s", err)
+	}
+	util.Logfn(e.logf, fmt.Sprintf("External sort spilled %d items", len(items)))
+	return nil
+}
+
+// merge does a k-way merge of the spilled runs
+func (e *externalSorter) merge(ctx context.Context) error {
+	readers := make([]*bufio.Reader, len(e.runs))
+	for i, run := range e.runs {
+		file, err := os.Open(run)
+		if err != nil {
+			return fmt.Errorf("SortExternal merge failed: %s", err)
+		}
+		defer file.Close()
+		readers[i] = bufio.NewReader(file)
+	}
+
+	h := &mergeHeap{less: e.less}
+	for i := range readers {
+		item, err := e.read(readers[i])
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.heads = append(h.heads, mergeHead{item: item, src: i})
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		head := heap.Pop(h).(mergeHead)
+		select {
+		case e.output <- head.item:
+		case <-ctx.Done():
+			return nil
+		}
+		item, err := e.read(readers[head.src])
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		heap.Push(h, mergeHead{item: item, src: head.src})
+	}
+	return nil
+}
+
+// verify ensures that data, the encoding of item, decodes back to item, so
+// that spilled items are not altered (i.e. unexported fields zeroed by gob)
+func (e *externalSorter) verify(data []byte, item interface{}) error {
+	decoded, err := e.decode(data)
+	if err != nil {
+		return fmt.Errorf("SortExternal spill failed: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, item) {
+		return fmt.Errorf("SortExternal spill failed: codec %T does not round-trip items of type %s", e.codec, e.itemType)
+	}
+	return nil
+}
+
+// decode unmarshals data into a new value of the item type
+func (e *externalSorter) decode(data []byte) (interface{}, error) {
+	item := reflect.New(e.itemType)
+	if err := e.codec.Unmarshal(data, item.Interface()); err != nil {
+		return nil, err
+	}
+	return item.Elem().Interface(), nil
+}
+
+// read reads the next item from a run, returns io.EOF at the end of the run
+func (e *externalSorter) read(reader *bufio.Reader) (interface{}, error) {
+	size, err := binary.ReadUvarint(reader)
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SortExternal merge failed: %s", err)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, fmt.Errorf("SortExternal merge failed: %s", err)
+	}
+	item, err := e.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("SortExternal merge failed: %s", err)
+	}
+	return item, nil
+}
+
+// report reports err, with item, to the error function
+func (e *externalSorter) report(err error, item interface{}) {
+	util.Logfn(e.logf, err)
+	autoctx.Err(e.errf, api.ErrorWithItem(err.Error(), &api.StreamItem{Item: item}))
+}
+
+// fail aborts the stream with err, spilled runs are discarded
+func (e *externalSorter) fail(err error) {
+	util.Logfn(e.logf, err)
+	e.abort(err)
+}
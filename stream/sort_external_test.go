@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/codec"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_SortExternal(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "automi-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	oldTmp := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", tmpDir)
+	defer os.Setenv("TMPDIR", oldTmp)
+
+	type reading struct {
+		Sensor string
+		Value  int
+	}
+	data := make([]reading, 100)
+	for i, v := range rand.Perm(len(data)) {
+		data[i] = reading{Sensor: "s", Value: v}
+	}
+	less := func(a, b interface{}) bool {
+		return a.(reading).Value < b.(reading).Value
+	}
+
+	tests := []struct {
+		name   string
+		maxMem int
+	}{
+		{name: "in memory", maxMem: 1000},
+		{name: "spilled runs", maxMem: 7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snk := collectors.Slice()
+			strm := New(emitters.Slice(data)).SortExternal(less, test.maxMem, codec.Gob()).Into(snk)
+
+			select {
+			case err := <-strm.Open():
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Took too long")
+			}
+
+			result := snk.Get()
+			if len(result) != len(data) {
+				t.Fatal("unexpected result count:", len(result))
+			}
+			if !sort.SliceIsSorted(result, func(i, j int) bool { return less(result[i], result[j]) }) {
+				t.Fatal("result not sorted:", result)
+			}
+			if !reflect.DeepEqual(result[0], reading{"s", 0}) {
+				t.Fatal("unexpected first item:", result[0])
+			}
+
+			files, err := ioutil.ReadDir(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 0 {
+				t.Fatal("temporary files not removed:", len(files))
+			}
+		})
+	}
+}
+
+func TestStream_SortExternal_MixedTypes(t *testing.T) {
+	errs := 0
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]interface{}{3, "x", 1, 2})).
+		WithErrorFunc(func(api.StreamError) {
+			errs++
+		}).
+		SortExternal(func(a, b interface{}) bool {
+			return a.(int) < b.(int)
+		}, 2, codec.Gob()).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{1, 2, 3}) || errs != 1 {
+		t.Fatal("unexpected result:", snk.Get(), "errors:", errs)
+	}
+}
+
+func TestStream_SortExternal_Codec(t *testing.T) {
+	type entry struct {
+		K    int
+		note string
+	}
+	type private struct{ k int }
+
+	tests := []struct {
+		name string
+		data interface{}
+		less func(a, b interface{}) bool
+	}{
+		{
+			name: "unexported fields dropped",
+			data: []entry{{3, "c"}, {1, "a"}, {2, "b"}},
+			less: func(a, b interface{}) bool { return a.(entry).K < b.(entry).K },
+		},
+		{
+			name: "no exported fields",
+			data: []private{{3}, {1}, {2}},
+			less: func(a, b interface{}) bool { return a.(private).k < b.(private).k },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snk := collectors.Slice()
+			strm := New(emitters.Slice(test.data)).SortExternal(test.less, 2, codec.Gob()).Into(snk)
+
+			select {
+			case err := <-strm.Open():
+				if err == nil {
+					t.Fatal("expecting spill error, got result:", snk.Get())
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Took too long")
+			}
+		})
+	}
+}
+
+func TestStream_SortExternal_Invalid(t *testing.T) {
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+	strm := New(emitters.Slice([]int{2, 1})).SortExternal(less, 2, nil).Into(collectors.Slice())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error without codec")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
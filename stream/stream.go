@@ -3,9 +3,13 @@ package stream
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/taiyang-li/automi/api"
 	autoctx "github.com/taiyang-li/automi/api/context"
@@ -18,6 +22,8 @@ import (
 // Stream represents a stream unto  which executor nodes can be
 // attached to operate on the streamed data
 type Stream struct {
+	errCount    int64 // accessed atomically, kept first for 64-bit alignment
+	name        string
 	srcParam    interface{}
 	snkParam    interface{}
 	source      api.Source
@@ -29,6 +35,32 @@ type Stream struct {
 	errf        api.ErrorFunc
 	concurrency int
 	bufferSize  int
+	pool        *sync.Pool
+	maxItems    int64
+	prefetch    int
+	checkpoint  *checkpointer
+	timeout     *watchdog
+	hashSeed    uint64
+	collectMode collectors.BroadcastMode
+	instrument  bool
+	tracing     bool
+	opNames     map[api.Operator]string
+	latency     map[string]*latencyRecorder
+	probe       *startupProbe
+	cancel      context.CancelFunc
+	failMutex   sync.Mutex
+	failure     error
+	buildErr    error
+	errSinks    []*ErrorSink
+	errSnk      *sideSink
+	deadLetter  *sideSink
+	sideSinks   []*sideSink
+	errPolicy   ErrorPolicy
+	maxErrors   int64
+
+	backpressureFn    func(string, time.Duration)
+	backpressureAfter time.Duration
+	metrics           Metrics
 }
 
 // New creates a new *Stream value
@@ -43,12 +75,63 @@ func New(src interface{}) *Stream {
 	return s
 }
 
-// WithContext sets a context.Context to use.
+// FromSlice creates a new *Stream value that emits the elements of slice,
+// which can be of any slice type []T.  If slice is not a slice, the stream
+// returns an error when opened.
+func FromSlice(slice interface{}) *Stream {
+	sliceType := reflect.TypeOf(slice)
+	if sliceType == nil || sliceType.Kind() != reflect.Slice {
+		s := New(slice)
+		s.drainErr(fmt.Errorf("FromSlice expects a slice, got %T", slice))
+		return s
+	}
+	return New(emitters.Slice(slice))
+}
+
+// FromChan creates a new *Stream value that emits the items received from
+// channel ch, which can be of type chan T or <-chan T.  The stream completes
+// when ch is closed.  If ch is not a receive channel, the stream returns an
+// error when opened.
+func FromChan(ch interface{}) *Stream {
+	if !util.IsRecvChan(ch) || reflect.ValueOf(ch).IsNil() {
+		s := New(ch)
+		s.drainErr(fmt.Errorf("FromChan expects a receive channel, got %T", ch))
+		return s
+	}
+	return New(emitters.Chan(ch))
+}
+
+// FromReader creates a new *Stream value that emits the content read from r
+// as chunks of []byte (see emitters.Reader).  If r is nil, the stream returns
+// an error when opened.
+func FromReader(r io.Reader) *Stream {
+	if r == nil {
+		s := New(nil)
+		s.drainErr(errors.New("FromReader expects a non-nil io.Reader"))
+		return s
+	}
+	return New(emitters.Reader(r))
+}
+
+// WithContext sets the context.Context of the stream and returns the stream
+// for chaining, as in New(src).WithContext(ctx).Filter(...).  The context is
+// applied when the stream is opened, therefore all operators receive it,
+// including those appended before WithContext is called.  Cancelling ctx
+// cancels the stream.
 func (s *Stream) WithContext(ctx context.Context) *Stream {
 	s.ctx = ctx
 	return s
 }
 
+// WithName sets a name used to identify the stream when several streams run
+// in the same process.  The name is stored in the context (see package
+// context#GetStreamName) and all log events, from the stream and its
+// components, are tagged with the name as "<name>: <log event>".
+func (s *Stream) WithName(name string) *Stream {
+	s.name = name
+	return s
+}
+
 // WithLogFunc sets a function that will receive internal log events
 // at runtime.  Supported log function type: func(interface{})
 func (s *Stream) WithLogFunc(fn api.LogFunc) *Stream {
@@ -64,6 +147,15 @@ func (s *Stream) WithErrorFunc(fn api.ErrorFunc) *Stream {
 	return s
 }
 
+// WithConcurrency sets the number of goroutines used by each unary operator
+// (i.e. Process, Map, Filter) to apply its function to incoming items.  It
+// applies to the operators added to the stream after it is called, for instance:
+//
+//   strm.Map(parse).WithConcurrency(4).Map(checksum)
+//
+// runs checksum with 4 goroutines while parse remains single-threaded.  When
+// concurrency is greater than 1, the order of items is no longer preserved
+// downstream.  Values less than 1 are set to 1 (the default).
 func (s *Stream) WithConcurrency(concurrency int) *Stream {
 	if concurrency < 1 {
 		concurrency = 1
@@ -72,6 +164,13 @@ func (s *Stream) WithConcurrency(concurrency int) *Stream {
 	return s
 }
 
+// WithBufferSize sets the buffer size of the output channel of the operators
+// added to the stream after it is called (1024 by default).  Large buffers
+// improve throughput of large streams, at the cost of memory, while small
+// buffers apply backpressure sooner.  The size applies to all the operators
+// added with the stream methods (i.e. Map, Batch, Take or Throttle); operators
+// that the stream inserts itself (i.e. for Prefetch or TraceItems) keep the
+// default size.  Values less than 1 are set to 1.
 func (s *Stream) WithBufferSize(bufferSize int) *Stream {
 	if bufferSize < 1 {
 		bufferSize = 1
@@ -80,6 +179,65 @@ func (s *Stream) WithBufferSize(bufferSize int) *Stream {
 	return s
 }
 
+// WithPool sets up a sync.Pool-backed resource pool, using newFn to create
+// new resources, that is accessible to operator functions via the context:
+//
+//   strm.WithPool(func() interface{} { return new(bytes.Buffer) })
+//   strm.Map(func(ctx context.Context, item string) string {
+//       pool := autoctx.GetPool(ctx)
+//       buf := pool.Get().(*bytes.Buffer)
+//       defer pool.Put(buf)
+//       buf.Reset() // always reset pooled objects before use
+//       ...
+//   })
+//
+// A resource obtained with Get must be returned with Put once the function
+// is done with it and must not be retained or sent downstream after that.
+// Since pooled objects are reused, they must be reset before reuse.
+func (s *Stream) WithPool(newFn func() interface{}) *Stream {
+	s.pool = &sync.Pool{New: newFn}
+	return s
+}
+
+// WithMaxItems sets a safety limit on the number of items that can be
+// emitted by the stream source.  When the source emits more than n items,
+// the stream is cancelled and Open() returns an error.  Unlike operator Take,
+// exceeding the limit is treated as a failure, it is meant as a safety valve
+// for misbehaving sources that never terminate.
+func (s *Stream) WithMaxItems(n int64) *Stream {
+	s.maxItems = n
+	return s
+}
+
+// WithHashSeed sets the seed used to hash item keys by the partitioning
+// operations of the stream (currently ShardBy).  The default seed is fixed
+// (0), therefore the assignment of keys to partitions is already reproducible
+// across runs; a different seed yields a different, equally reproducible,
+// assignment which is useful for golden tests or to rebalance skewed keys.
+func (s *Stream) WithHashSeed(seed uint64) *Stream {
+	s.hashSeed = seed
+	return s
+}
+
+// WithConcurrentCollectors selects how IntoAll writes items to its sinks:
+// collectors.BroadcastSequential (default) writes to each sink in turn,
+// collectors.BroadcastConcurrent writes to all sinks concurrently for each item,
+// and collectors.BroadcastBuffered decouples the sinks with per-sink buffers.
+func (s *Stream) WithConcurrentCollectors(mode collectors.BroadcastMode) *Stream {
+	s.collectMode = mode
+	return s
+}
+
+// PrefetchSource places a buffer of n items between the stream source
+// and the first operator.  A goroutine eagerly pulls items from the source
+// into the buffer, ahead of the operators, to smooth out sources with
+// variable per-item latency (i.e. paged network calls).  Prefetching stops
+// when the stream is cancelled.
+func (s *Stream) PrefetchSource(n int) *Stream {
+	s.prefetch = n
+	return s
+}
+
 // From sets the stream source to use
 //func (s *Stream) From(src api.StreamSource) *Stream {
 //	s.source = src
@@ -92,23 +250,85 @@ func (s *Stream) Into(snk interface{}) *Stream {
 	return s
 }
 
+// IntoChan sets a typed channel ch, of type chan T or chan<- T, as the
+// stream sink.  Collected items are sent on ch, items that are not assignable
+// to T are reported to the error function.  Channel ch is closed when the stream
+// completes which lets strongly-typed consumers range over the results.
+//
+// See Also
+//
+// See the collector
+//   "github.com/taiyang-li/automi/collectors"#Chan
+func (s *Stream) IntoChan(ch interface{}) *Stream {
+	if !util.IsSendChan(ch) || reflect.ValueOf(ch).IsNil() {
+		s.drainErr(fmt.Errorf("IntoChan expects a non-nil send channel, got %T", ch))
+		return s
+	}
+	return s.Into(ch)
+}
+
+// IntoByType sets the stream sink to route each item to the sink registered
+// for its dynamic type in routes, or to defaultSink when its type is not
+// registered.  If defaultSink is nil, unroutable items are reported to the
+// error function.  All sinks are opened and closed with the stream.
+//
+// See Also
+//
+// See the collector
+//   "github.com/taiyang-li/automi/collectors"#ByType
+func (s *Stream) IntoByType(routes map[reflect.Type]api.Sink, defaultSink api.Sink) *Stream {
+	return s.Into(collectors.ByType(routes, defaultSink))
+}
+
+// IntoAll sets the stream sink to write each item to all of the provided
+// sinks.  The errors from all sinks are aggregated in the error returned
+// by the stream.  Use WithConcurrentCollectors to select how the sinks
+// are written.
+//
+// See Also
+//
+// See the collector
+//   "github.com/taiyang-li/automi/collectors"#Broadcast
+func (s *Stream) IntoAll(sinks ...api.Sink) *Stream {
+	if len(sinks) == 0 {
+		s.drainErr(errors.New("IntoAll expects at least one sink"))
+		return s
+	}
+	return s.Into(collectors.Broadcast(sinks...))
+}
+
 // ReStream takes upstream items of types []slice []array, map[T]
 // and emmits their elements as individual channel items to downstream
 // operations.  Items of other types are ignored.
 func (s *Stream) ReStream() *Stream {
-	sop := streamop.New()
-	s.ops = append(s.ops, sop)
-	return s
+	return s.appendOp(streamop.New())
+}
+
+// Flatten emits the elements of upstream items of type slice or array as
+// individual items downstream, so that a stream of []T becomes a stream of T.
+// Map items are emitted as a tuple.KV{key, value} for each entry.  It is
+// equivalent to ReStream.
+func (s *Stream) Flatten() *Stream {
+	return s.ReStream()
 }
 
 // Open opens the Stream which executes all operators nodes.
-// If there's an issue prior to execution, an error is returned
-// in the error channel.
+// If there's an issue prior to execution, such as an invalid argument
+// passed while building the stream, an error is returned in the error
+// channel and the stream is not executed.
 func (s *Stream) Open() <-chan error {
+	// an error recorded while building the stream aborts it before any
+	// of its components is started
+	if s.buildErr != nil {
+		go func() { s.drain <- s.buildErr }()
+		return s.drain
+	}
+
 	s.prepareContext() // ensure context is set
 
 	if err := s.initGraph(); err != nil {
-		s.drainErr(err)
+		s.cancel()
+		go func() { s.drain <- err }()
 		return s.drain
 	}
 
@@ -116,24 +336,59 @@ func (s *Stream) Open() <-chan error {
 
 	// open stream
 	go func() {
+		defer s.cancel()
+
+		// wait for dependencies to be ready
+		if s.probe != nil {
+			if err := s.probe.run(s.ctx, s.logf); err != nil {
+				s.drain <- err
+				return
+			}
+		}
+
+		// open side sinks first, to capture all errors
+		for _, snk := range s.sideSinks {
+			snk.open(s.ctx)
+		}
+
 		// open source, if err bail
 		if err := s.source.Open(s.ctx); err != nil {
-			s.drainErr(err)
+			s.drain <- err
 			return
 		}
 		//apply operators, if err bail
 		for _, op := range s.ops {
 			if err := op.Exec(s.ctx); err != nil {
-				s.drainErr(err)
+				s.drain <- err
 				return
 			}
 		}
 
+		// stop waiting for the sink once the timeout expired
+		var expired <-chan struct{}
+		if s.timeout != nil {
+			expired = s.timeout.expired
+		}
+
 		// open stream sink, after log sink is ready.
 		select {
 		case err := <-s.sink.Open(s.ctx):
 			util.Logfn(s.logf, "Closing stream")
+			for _, snk := range s.sideSinks {
+				if snkErr := snk.close(); err == nil {
+					err = snkErr
+				}
+			}
+			if failure := s.getFailure(); failure != nil {
+				err = failure
+			}
+			if err == nil && s.checkpoint != nil {
+				s.checkpoint.complete()
+			}
 			s.drain <- err
+		case <-expired:
+			util.Logfn(s.logf, "Stream timed out")
+			s.drain <- s.getFailure()
 		}
 	}()
 
@@ -146,8 +401,19 @@ func (s *Stream) prepareContext() {
 	if s.ctx == nil {
 		s.ctx = context.TODO()
 	}
+	s.ctx, s.cancel = context.WithCancel(s.ctx)
+	if s.name != "" {
+		s.ctx = autoctx.WithStreamName(s.ctx, s.name)
+		s.logf = namedLogFunc(s.name, s.logf)
+	}
 	s.ctx = autoctx.WithLogFunc(s.ctx, s.logf)
-	s.ctx = autoctx.WithErrorFunc(s.ctx, s.errf)
+	s.ctx = autoctx.WithErrorFunc(s.ctx, s.handleError)
+	if s.pool != nil {
+		s.ctx = autoctx.WithPool(s.ctx, s.pool)
+	}
+	if s.hashSeed != 0 {
+		s.ctx = autoctx.WithHashSeed(s.ctx, s.hashSeed)
+	}
 }
 
 // bindOps binds operator channels
@@ -179,6 +445,32 @@ func (s *Stream) initGraph() error {
 		return err
 	}
 
+	// report stalled and timed operators, before internal
+	// operators are added, to name them by position
+	s.setupBackpressure()
+	s.setupInstrumentation()
+	s.setupDeadLetter()
+	if err := s.setupCheckpoint(); err != nil {
+		return err
+	}
+	s.setupTracing()
+
+	// guard source with item limit
+	if s.maxItems > 0 {
+		s.ops = append([]api.Operator{newItemLimiter(s.maxItems, s.fail)}, s.ops...)
+	}
+
+	// decouple source with prefetch buffer
+	if s.prefetch > 0 {
+		s.ops = append([]api.Operator{newPrefetcher(s.prefetch)}, s.ops...)
+	}
+
+	// watch for sink inactivity
+	if s.timeout != nil {
+		s.timeout.abort = s.fail
+		s.ops = append(s.ops, s.timeout)
+	}
+
 	// if there are no ops, link source to sink
 	if len(s.ops) == 0 && s.sink != nil {
 		util.Logfn(s.logf, "No operators in stream, binding source to sink directly")
@@ -243,6 +535,11 @@ func (s *Stream) setupSink() error {
 
 	// check specific type
 	switch snk := s.snkParam.(type) {
+	case *collectors.BroadcastCollector:
+		if s.collectMode != collectors.BroadcastSequential {
+			snk.Mode(s.collectMode)
+		}
+		s.sink = snk
 	case api.Sink:
 		s.sink = snk
 	case string:
@@ -261,6 +558,7 @@ func (s *Stream) setupSink() error {
 		case reflect.Slice:
 			s.sink = collectors.Slice()
 		case reflect.Chan:
+			s.sink = collectors.Chan(s.snkParam)
 		}
 	}
 
@@ -271,6 +569,73 @@ func (s *Stream) setupSink() error {
 	return nil
 }
 
+// drainErr records err, raised while building the stream, to be returned
+// by Open.  Only the first error is kept.
 func (s *Stream) drainErr(err error) {
-	go func() { s.drain <- err }()
+	if s.buildErr == nil {
+		s.buildErr = err
+	}
+}
+
+// handleError is the error function used by all stream components.
+// It captures the error with registered error sinks then forwards
+// it to the user-provided error function.
+func (s *Stream) handleError(err api.StreamError) {
+	count := atomic.AddInt64(&s.errCount, 1)
+	for _, snk := range s.errSinks {
+		snk.add(err)
+	}
+	autoctx.Err(s.errf, err)
+	if s.errSnk != nil {
+		s.errSnk.send(s.ctx, err)
+	}
+	if s.deadLetter != nil && err.Item() != nil {
+		s.deadLetter.send(s.ctx, err)
+	}
+	var cancelErr api.CancelStreamError
+	if errors.As(err, &cancelErr) {
+		util.Logfn(s.logf, "Stream cancelled by operator")
+		if s.cancel != nil {
+			s.cancel()
+		}
+		return
+	}
+	if s.errPolicy == FailOnError {
+		s.fail(err)
+	}
+	if s.maxErrors > 0 && count == s.maxErrors {
+		s.fail(fmt.Errorf("stream aborted after %d errors, last error: %s", count, err))
+	}
+}
+
+// fail records the first failure that aborts the stream, then cancels
+// the stream context.  The failure is returned by Open() when the
+// stream closes.
+func (s *Stream) fail(err error) {
+	s.failMutex.Lock()
+	if s.failure == nil {
+		s.failure = err
+	}
+	s.failMutex.Unlock()
+	util.Logfn(s.logf, err)
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// getFailure returns the failure that aborted the stream, if any
+func (s *Stream) getFailure() error {
+	s.failMutex.Lock()
+	defer s.failMutex.Unlock()
+	return s.failure
+}
+
+// namedLogFunc wraps logf to tag log events with the stream name
+func namedLogFunc(name string, logf api.LogFunc) api.LogFunc {
+	if logf == nil {
+		return nil
+	}
+	return func(msg interface{}) {
+		logf(fmt.Sprintf("%s: %v", name, msg))
+	}
 }
@@ -1,7 +1,11 @@
 package stream
 
 import (
+	"errors"
+	"time"
+
 	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/codec"
 	"github.com/taiyang-li/automi/operators/batch"
 	"github.com/taiyang-li/automi/operators/unary"
 )
@@ -18,6 +22,49 @@ func (s *Stream) BatchBySize(size int64) *Stream {
 	return s.appendOp(operator)
 }
 
+// BatchByDuration batches incoming items and sends the batch downstream
+// every d (tumbling time window), then starts a new batch.  Empty batches
+// are not emitted and the last partial batch is emitted when the stream
+// completes.  As with Batch, the batch is a slice of the type of the
+// first batched item.  It can be combined with FlushEvery to also emit a
+// batch when it reaches a given size.
+func (s *Stream) BatchByDuration(d time.Duration) *Stream {
+	if d <= 0 {
+		s.drainErr(errors.New("BatchByDuration duration must be greater than zero"))
+		return s
+	}
+	operator := batch.New()
+	operator.SetTrigger(batch.TriggerAll())
+	operator.SetFlushInterval(d)
+	return s.appendOp(operator)
+}
+
+// FlushEvery changes the preceding Batch operator so that it emits a
+// batch every n items, instead of waiting for the end of the stream,
+// with the remaining items emitted as a last (smaller) batch:
+//
+//   strm.Batch().FlushEvery(100).Sum()
+//
+// This lets the batch functions (Sum, Sort, GroupBy, etc) operate on fixed
+// size chunks of unbounded streams.
+func (s *Stream) FlushEvery(n int) *Stream {
+	if n <= 0 {
+		s.drainErr(errors.New("FlushEvery size must be greater than zero"))
+		return s
+	}
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("FlushEvery must follow a Batch operator"))
+		return s
+	}
+	operator, ok := s.ops[len(s.ops)-1].(*batch.BatchOperator)
+	if !ok {
+		s.drainErr(errors.New("FlushEvery must follow a Batch operator"))
+		return s
+	}
+	operator.SetTrigger(batch.TriggerBySize(int64(n)))
+	return s
+}
+
 // GroupByKey groups incoming items that are batched as
 // type []map[K]V where parameter key is used to group
 // the items when K=key.  Items with same key values are
@@ -33,10 +80,37 @@ func (s *Stream) GroupByKey(key interface{}) *Stream {
 	return s.appendOp(operator)
 }
 
+// AggregateGroups applies function fn to each group of items produced by
+// the preceding GroupBy operator (i.e. GroupByKey), with the group key and its
+// items, and sends downstream a map[interface{}]interface{} that maps each group
+// key to its aggregate.  Groups for which fn returns nil are omitted.  The map
+// can be followed by ReStream to process each group aggregate as a tuple.KV:
+//
+//   strm.Batch().GroupByKey("Device").AggregateGroups(
+//       func(device interface{}, events []interface{}) interface{} {
+//           return len(events)
+//       },
+//   ).ReStream()
+//
+// See Also
+//
+// See batch operator function AggregateGroupsFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) AggregateGroups(fn func(group interface{}, items []interface{}) interface{}) *Stream {
+	if fn == nil {
+		s.drainErr(errors.New("AggregateGroups requires an aggregate function"))
+		return s
+	}
+	operator := unary.New()
+	operator.SetOperation(batch.AggregateGroupsFunc(fn))
+	return s.appendOp(operator)
+}
+
 // GroupByName groups incoming items that are batched as
 // type []T where T is a struct. Parameter name is used to select
 // T.name as key to group items with the same value into a map map[key][]T
-// that is sent downstream.
+// that is sent downstream.  The field tagged automi:"name" is selected
+// first, otherwise the field named name.
 //
 // See Also
 //
@@ -89,6 +163,19 @@ func (s *Stream) SortByKey(key interface{}) *Stream {
 	return s.appendOp(operator)
 }
 
+// SortByKeyDesc is similar to SortByKey but sorts the batched items in
+// descending order (largest first) of the values at key.
+//
+// See Also
+//
+// See also the operator function SortByKeyDescFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) SortByKeyDesc(key interface{}) *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.SortByKeyDescFunc(key))
+	return s.appendOp(operator)
+}
+
 // SortByName sorts incoming items that are batched as []T where
 // T struct with fields identified by param name.  Value struct.<name>
 // is used to sort the slice.  The operator returns stored slice []T.
@@ -117,6 +204,54 @@ func (s *Stream) SortByPos(pos int) *Stream {
 	return s.appendOp(operator)
 }
 
+// SortByPosDesc is similar to SortByPos but sorts the batched items in
+// descending order (largest first) of the values at position pos.
+//
+// See Also
+//
+// See also the operator function SortByPosDescFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) SortByPosDesc(pos int) *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.SortByPosDescFunc(pos))
+	return s.appendOp(operator)
+}
+
+// SortExternal sorts all the items of the stream using the less function,
+// even when they do not fit in memory.  Items are buffered up to maxMem items,
+// sorted and spilled as runs to temporary files, encoded with codec c, then
+// the runs are merged when the stream closes to emit the sorted items.  When
+// the stream has no more than maxMem items, they are sorted in memory.  Items
+// are expected to be of the same type, other items are reported to the error
+// function.
+//
+// Codec c must decode spilled items back to values equal to the originals
+// (see reflect.DeepEqual), for instance codec.Gob drops unexported fields.
+// Each spilled item is verified; an item that does not round-trip, or an IO
+// error, aborts the stream and the error is returned by Open.  Temporary files
+// are removed when the operator completes or is cancelled.
+func (s *Stream) SortExternal(less func(a, b interface{}) bool, maxMem int, c codec.Codec) *Stream {
+	if less == nil || maxMem <= 0 || c == nil {
+		s.drainErr(errors.New("SortExternal requires a less function, a positive maxMem and a codec"))
+		return s
+	}
+	return s.appendOp(newExternalSorter(less, maxMem, c, s.fail))
+}
+
+// SortBy sorts incoming items that are batched as []T using the less
+// function which reports whether item a must sort before item b.  The sort
+// is stable, equal items keep their arrival order.
+//
+// See Also
+//
+// See also the operator function SortByFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) SortBy(less func(a, b interface{}) bool) *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.SortByFunc(less))
+	return s.appendOp(operator)
+}
+
 // SortWith sorts incoming items that are batched as []T using the
 // provided Less function for applicaiton with the sort package.
 //
@@ -130,6 +265,67 @@ func (s *Stream) SortWith(f func(batch interface{}, i, j int) bool) *Stream {
 	return s.appendOp(operator)
 }
 
+// Count emits the number of items, as an int, of incoming items batched
+// as a slice, an array or a map.  Items of type tuple.KV, such as groups
+// restreamed after a GroupBy operation, are counted as tuple.KV{key, count}:
+//
+//   strm.Batch().GroupByKey("Device").ReStream().ReStream().Count()
+//
+// See Also
+//
+// See also the operator function CountFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) Count() *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.CountFunc())
+	return s.appendOp(operator)
+}
+
+// Average computes the arithmetic mean of numeric items that are batched
+// as []T or [][]T where T is an integer or a floating point value. The
+// operator returns a single value of type float64.  A batch without numeric
+// items is reported as an error.
+//
+// See Also
+//
+// See also the operator function AvgFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) Average() *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.AvgFunc())
+	return s.appendOp(operator)
+}
+
+// Min emits the smallest of the numeric items that are batched as []T,
+// where T is an integer or a floating point type, with its original type.
+// Empty batches and batches with mixed or non-numeric types are reported
+// as errors.
+//
+// See Also
+//
+// See also the operator function MinFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) Min() *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.MinFunc())
+	return s.appendOp(operator)
+}
+
+// Max emits the largest of the numeric items that are batched as []T,
+// where T is an integer or a floating point type, with its original type.
+// Empty batches and batches with mixed or non-numeric types are reported
+// as errors.
+//
+// See Also
+//
+// See also the operator function MaxFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) Max() *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.MaxFunc())
+	return s.appendOp(operator)
+}
+
 // Sum sums up numeric items that are batched as []T or [][]T where
 // T is an integer or a floating point value. The operator returns a
 // single value of type float64.
@@ -199,8 +395,42 @@ func (s *Stream) SumByPos(pos int) *Stream {
 	return s.appendOp(operator)
 }
 
+// FrequencyTable counts the occurrences of each distinct value in items
+// that are batched as []T, where T is comparable (when key == nil).  When
+// key is provided, items batched as []map[K]V are counted by their value
+// for K=key and items batched as []struct are counted by the value of field
+// named key.  The operator returns a map[interface{}]int which, being a map,
+// has no defined order.  Use SortedFrequencyTable for a deterministic output.
+//
+// See Also
+//
+// See also the operator function FrequencyFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) FrequencyTable(key interface{}) *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.FrequencyFunc(key))
+	return s.appendOp(operator)
+}
+
+// SortedFrequencyTable works like FrequencyTable but returns the table
+// as []tuple.KV{value, count} sorted by descending counts, then by
+// ascending values for equal counts.
+//
+// See Also
+//
+// See also the operator function SortedFrequencyFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) SortedFrequencyTable(key interface{}) *Stream {
+	operator := unary.New()
+	operator.SetOperation(batch.SortedFrequencyFunc(key))
+	return s.appendOp(operator)
+}
+
 // GroupByKey
 func (s *Stream) appendOp(operator api.Operator) *Stream {
+	if sized, ok := operator.(interface{ SetBufferSize(int) }); ok {
+		sized.SetBufferSize(s.bufferSize)
+	}
 	s.ops = append(s.ops, operator)
 	return s
 }
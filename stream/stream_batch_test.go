@@ -1,9 +1,12 @@
 package stream
 
 import (
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/taiyang-li/automi/api/tuple"
 	"github.com/taiyang-li/automi/collectors"
 	"github.com/taiyang-li/automi/emitters"
 )
@@ -198,6 +201,71 @@ func TestStream_SortByPos(t *testing.T) {
 	}
 }
 
+func TestStream_SortBy(t *testing.T) {
+	type planet struct {
+		Name   string
+		Radius int
+	}
+	src := emitters.Slice([]planet{
+		{"Mercury", 2439},
+		{"Venus", 6051},
+		{"Earth", 6371},
+		{"Mars", 3389},
+	})
+
+	snk := collectors.Slice()
+	strm := New(src).Batch().SortBy(func(a, b interface{}) bool {
+		return a.(planet).Radius < b.(planet).Radius
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()[0].([]planet)
+	var names []string
+	for _, p := range result {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Mercury", "Mars", "Venus", "Earth"}) {
+		t.Fatal("unexpected sort order", names)
+	}
+}
+
+func TestStream_SortDesc(t *testing.T) {
+	posSnk, keySnk := collectors.Slice(), collectors.Slice()
+	rows := [][]interface{}{{"Mercury", 4879}, {"Venus", 12104}, {"Earth", 12742}}
+	maps := []map[string]int{{"Radius": 4879}, {"Radius": 12104}, {"Radius": 12742}}
+
+	for _, strm := range []*Stream{
+		New(emitters.Slice(rows)).Batch().SortByPosDesc(1).Into(posSnk),
+		New(emitters.Slice(maps)).Batch().SortByKeyDesc("Radius").Into(keySnk),
+	} {
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+	}
+
+	byPos := posSnk.Get()[0].([][]interface{})
+	if byPos[0][0] != "Earth" || byPos[1][0] != "Venus" || byPos[2][0] != "Mercury" {
+		t.Fatal("unexpected sort order", byPos)
+	}
+	byKey := keySnk.Get()[0].([]map[string]int)
+	if byKey[0]["Radius"] != 12742 || byKey[2]["Radius"] != 4879 {
+		t.Fatal("unexpected sort order", byKey)
+	}
+}
+
 func TestStream_SortWith(t *testing.T) {
 	src := emitters.Slice([]string{
 		"Mercury",
@@ -254,6 +322,148 @@ func TestStream_Sum(t *testing.T) {
 	}
 }
 
+func TestStream_Count(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c"})).Batch().Count().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{3}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_Count_PerGroup(t *testing.T) {
+	src := emitters.Slice([]map[string]string{
+		{"Device": "AA", "Event": "request"},
+		{"Device": "BB", "Event": "request"},
+		{"Device": "AA", "Event": "response"},
+	})
+
+	counts := make(map[interface{}]interface{})
+	strm := New(src).Batch().GroupByKey("Device").ReStream().ReStream().Count().
+		Into(collectors.Func(func(item interface{}) error {
+			kv := item.(tuple.KV)
+			counts[kv[0]] = kv[1]
+			return nil
+		}))
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := map[interface{}]interface{}{"AA": 2, "BB": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatal("unexpected counts:", counts)
+	}
+}
+
+func TestStream_AggregateGroups(t *testing.T) {
+	src := emitters.Slice([]map[string]string{
+		{"Device": "AA", "Bytes": "10"},
+		{"Device": "BB", "Bytes": "5"},
+		{"Device": "AA", "Bytes": "20"},
+	})
+
+	totals := make(map[interface{}]interface{})
+	strm := New(src).Batch().GroupByKey("Device").
+		AggregateGroups(func(device interface{}, events []interface{}) interface{} {
+			total := 0
+			for _, event := range events {
+				bytes, _ := strconv.Atoi(event.(map[string]string)["Bytes"])
+				total += bytes
+			}
+			return total
+		}).
+		ReStream().
+		Into(collectors.Func(func(item interface{}) error {
+			kv := item.(tuple.KV)
+			totals[kv[0]] = kv[1]
+			return nil
+		}))
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := map[interface{}]interface{}{"AA": 30, "BB": 5}
+	if !reflect.DeepEqual(totals, expected) {
+		t.Fatal("unexpected totals:", totals)
+	}
+}
+
+func TestStream_Average(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6, 7})).Batch().FlushEvery(4).Average().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{2.5, 6.0}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_MinMax(t *testing.T) {
+	minSnk, maxSnk := collectors.Slice(), collectors.Slice()
+	items := []int{4879, 12104, 50724, 116464, 12742}
+
+	for _, strm := range []*Stream{
+		New(emitters.Slice(items)).Batch().Min().Into(minSnk),
+		New(emitters.Slice(items)).Batch().Max().Into(maxSnk),
+	} {
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+	}
+
+	if !reflect.DeepEqual(minSnk.Get(), []interface{}{4879}) {
+		t.Fatal("unexpected min:", minSnk.Get())
+	}
+	if !reflect.DeepEqual(maxSnk.Get(), []interface{}{116464}) {
+		t.Fatal("unexpected max:", maxSnk.Get())
+	}
+}
+
+func TestStream_Min_Error(t *testing.T) {
+	items, errs, err := New(emitters.Slice([]string{"a", "b"})).Batch().Min().CollectSliceE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 || len(errs) != 1 {
+		t.Fatal("expecting one error and no items, got", items, errs)
+	}
+}
+
 func TestStream_SumByKey(t *testing.T) {
 	src := emitters.Slice([]map[string]int{
 		{"Diameter": 4879},
@@ -330,3 +540,123 @@ func TestStream_SumByPos(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_FrequencyTable(t *testing.T) {
+	src := emitters.Slice([]map[string]string{
+		{"Event": "request", "Device": "00:11:51:AA"},
+		{"Event": "response", "Device": "00:11:51:AA"},
+		{"Event": "request", "Device": "00:11:22:33"},
+		{"Event": "request", "Device": "00:BB:22:DD"},
+	})
+
+	snk := collectors.Slice()
+	strm := New(src).Batch().FrequencyTable("Event").Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := snk.Get()[0].(map[interface{}]int)
+		if result["request"] != 3 || result["response"] != 1 {
+			t.Fatal("unexpected result:", result)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_SortedFrequencyTable(t *testing.T) {
+	src := emitters.Slice([]string{"b", "a", "c", "a", "b", "a"})
+
+	snk := collectors.Slice()
+	strm := New(src).Batch().SortedFrequencyTable(nil).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := snk.Get()[0].([]tuple.KV)
+		expected := []tuple.KV{{"a", 3}, {"b", 2}, {"c", 1}}
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatal("unexpected result:", result)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_FlushEvery(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6, 7})).Batch().FlushEvery(3).Sum().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []interface{}{6.0, 15.0, 7.0}
+		if !reflect.DeepEqual(snk.Get(), expected) {
+			t.Fatal("unexpected result:", snk.Get())
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_BatchByDuration(t *testing.T) {
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+		ch <- 2
+		time.Sleep(40 * time.Millisecond)
+		ch <- 3
+		ch <- 4
+		close(ch)
+	}()
+
+	snk := collectors.Slice()
+	strm := New(ch).BatchByDuration(20 * time.Millisecond).Sum().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{3.0, 7.0}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_BatchByDuration_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).BatchByDuration(0)
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for zero duration")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_FlushEvery_WithoutBatch(t *testing.T) {
+	strm := New(emitters.Slice([]int{1, 2, 3})).Map(func(i int) int {
+		return i
+	}).FlushEvery(3).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error when FlushEvery does not follow Batch")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
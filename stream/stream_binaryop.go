@@ -1,6 +1,11 @@
 package stream
 
-import "github.com/taiyang-li/automi/operators/binary"
+import (
+	"context"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/operators/binary"
+)
 
 // Reduce accumulates and reduces items from upstream into a
 // single value using the initial seed value and the reduction
@@ -19,6 +24,54 @@ func (s *Stream) Reduce(seed, f interface{}) *Stream {
 	}
 	operator.SetOperation(op)
 	operator.SetInitialState(seed)
-	s.ops = append(s.ops, operator)
-	return s
+	return s.appendOp(operator)
+}
+
+// Scan accumulates items from upstream, like Reduce, using the initial seed
+// value and the binary function f, but emits the accumulated value downstream
+// after each item (i.e. a running sum).  The provided function must be of type:
+//   func(S, T) R
+//     where S is the type of the partial result
+//     T is the incoming item from the stream
+//     R is the type of the result, to be used in the next call
+func (s *Stream) Scan(seed, f interface{}) *Stream {
+	operator := binary.New()
+	op, err := binary.ReduceFunc(f)
+	if err != nil {
+		s.drainErr(err)
+	}
+	operator.SetOperation(op)
+	operator.SetInitialState(seed)
+	operator.SetRunning(true)
+	return s.appendOp(operator)
+}
+
+// Aggregate folds all items from upstream into a mutable accumulator and
+// emits a single finished result. It uses three functions:
+//   newAcc - supplies a new accumulator (i.e. a map or a pointer to a struct)
+//   add    - updates the accumulator, in place, with each incoming item
+//   result - finishes the accumulator into the value emitted downstream
+// This is more expressive than Reduce when the accumulator differs from the
+// emitted result (i.e. accumulate into a map, emit a sorted slice).
+// If result is nil, the accumulator itself is emitted.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/binary"#AggregateFunc
+func (s *Stream) Aggregate(
+	newAcc func() interface{},
+	add func(acc, item interface{}),
+	result func(acc interface{}) interface{},
+) *Stream {
+	operator := binary.New()
+	operator.SetOperation(binary.AggregateFunc(add))
+	operator.SetInitialState(newAcc())
+	s.appendOp(operator)
+
+	if result == nil {
+		return s
+	}
+	return s.Transform(api.UnFunc(func(ctx context.Context, acc interface{}) interface{} {
+		return result(acc)
+	}))
 }
@@ -1,6 +1,8 @@
 package stream
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -29,3 +31,78 @@ func TestStream_Reduce(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_Scan(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).Scan(0, func(op1, op2 int) int {
+		return op1 + op2
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{1, 3, 6, 10, 15}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected running results:", snk.Get())
+	}
+}
+
+func TestStream_Aggregate(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"b", "a", "c", "a", "b"})).Aggregate(
+		func() interface{} {
+			return make(map[string]struct{})
+		},
+		func(acc, item interface{}) {
+			acc.(map[string]struct{})[item.(string)] = struct{}{}
+		},
+		func(acc interface{}) interface{} {
+			var keys []string
+			for key := range acc.(map[string]struct{}) {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return keys
+		},
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		val := snk.Get()[0].([]string)
+		if !reflect.DeepEqual(val, []string{"a", "b", "c"}) {
+			t.Fatal("unexpected aggregate result:", val)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_Reduce_EmptyInput(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		Filter(func(i int) bool { return i > 10 }).
+		Reduce(nil, func(op1 interface{}, op2 int) interface{} {
+			return op2
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if len(snk.Get()) != 0 {
+		t.Fatal("expecting no result on empty input, got", snk.Get())
+	}
+}
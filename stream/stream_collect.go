@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"errors"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+)
+
+// CollectChan opens the stream and returns a channel that yields each item
+// that reaches the end of the stream along with a channel for the terminal
+// stream error.  This is useful to range over the results of a stream
+// from existing channel-based code without setting up a collector:
+//
+//   items, errs := stream.New(src).Map(fn).CollectChan()
+//   for item := range items {...}
+//   if err := <-errs; err != nil {...}
+//
+// Both channels are closed when the stream completes or when its
+// context is cancelled.  Any sink previously set with Into is replaced.
+func (s *Stream) CollectChan() (<-chan interface{}, <-chan error) {
+	drn := NewDrain()
+	s.Into(drn)
+
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		err := <-s.Open()
+		// stream may fail before drain is opened
+		drn.closeOutput()
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return drn.GetOutput(), errs
+}
+
+// CollectSliceE runs the stream to completion and returns the items that
+// reach the end of the stream, all the stream errors reported during the run,
+// and the terminal stream error.  This gives full visibility into runs with
+// partial failures.  Errors are still forwarded to the error function.
+func (s *Stream) CollectSliceE() ([]interface{}, []api.StreamError, error) {
+	snk := collectors.Slice()
+	errs := s.TapErrors(0)
+	s.Into(snk)
+	err := <-s.Open()
+	return snk.Get(), errs.Get(), err
+}
+
+// CollectInto runs the stream to completion, appending the items that reach
+// the end of the stream to the slice pointed to by dst (reusing its existing
+// capacity), and returns the terminal stream error.  Slice *dst must not be
+// accessed while the stream is running and must not be shared with other
+// streams running at the same time.
+func (s *Stream) CollectInto(dst *[]interface{}) error {
+	if dst == nil {
+		return errors.New("CollectInto requires a slice pointer")
+	}
+	s.Into(collectors.Func(func(item interface{}) error {
+		*dst = append(*dst, item)
+		return nil
+	}))
+	return <-s.Open()
+}
+
+// Drain runs the stream to completion for its side effects, discarding
+// the items that reach the end of the stream.  It is equivalent to
+//
+//   <-strm.Into(collectors.Null()).Open()
+//
+// Runtime errors are still reported to the error function, if any.
+// The method blocks until the stream completes and returns the terminal
+// stream error or nil.
+func (s *Stream) Drain() error {
+	s.Into(collectors.Null())
+	return <-s.Open()
+}
+
+// CountByKey runs the stream to completion and returns the count of items
+// for each key computed with keyFn (if keyFn is nil, the item itself is used
+// as key).  Only the counter map is retained, not the items, making it
+// suitable for high-cardinality streams (i.e. word count).  Items with keys
+// that are not comparable are reported to the error function.
+//
+// See Also
+//
+// See the collector
+//   "github.com/taiyang-li/automi/collectors"#CountByKey
+func (s *Stream) CountByKey(keyFn func(interface{}) interface{}) (map[interface{}]int64, error) {
+	snk := collectors.CountByKey(keyFn)
+	s.Into(snk)
+	if err := <-s.Open(); err != nil {
+		return nil, err
+	}
+	return snk.Get(), nil
+}
+
+// GroupInto runs the stream to completion and returns a map of the items
+// grouped by the key computed with keyFn (if keyFn is nil, the item itself
+// is used as key).  Items are stored in the order they reach the end of the
+// stream.  Items with keys that are not comparable values are reported to
+// the error function.
+//
+// See Also
+//
+// See the collector
+//   "github.com/taiyang-li/automi/collectors"#GroupByKey
+func (s *Stream) GroupInto(keyFn func(interface{}) interface{}) (map[interface{}][]interface{}, error) {
+	snk := collectors.GroupByKey(keyFn)
+	s.Into(snk)
+	if err := <-s.Open(); err != nil {
+		return nil, err
+	}
+	return snk.Get(), nil
+}
+
+// CollectMap runs the stream to completion and returns a map where each
+// item is stored as valFn(item) at key keyFn(item). If valFn is nil, the item
+// itself is stored. The last value collected for a duplicate key wins, use
+// CollectMapWith to specify how duplicate keys are handled.
+//
+// See Also
+//
+// See the collector
+//   "github.com/taiyang-li/automi/collectors"#Map
+func (s *Stream) CollectMap(keyFn, valFn func(interface{}) interface{}) (map[interface{}]interface{}, error) {
+	return s.CollectMapWith(keyFn, valFn, collectors.LastWins)
+}
+
+// CollectMapWith is similar to CollectMap but uses the merge function to
+// resolve the values of duplicate keys (i.e. collectors.FailOnDuplicate,
+// collectors.FirstWins, or a custom function that merges the values).
+func (s *Stream) CollectMapWith(
+	keyFn, valFn func(interface{}) interface{},
+	merge collectors.MergeFunc,
+) (map[interface{}]interface{}, error) {
+	snk := collectors.Map(keyFn, valFn).Merge(merge)
+	s.Into(snk)
+	if err := <-s.Open(); err != nil {
+		return nil, err
+	}
+	return snk.Get(), nil
+}
@@ -0,0 +1,394 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_CollectChan(t *testing.T) {
+	items, errs := New(emitters.Slice([]string{"hello", "world"})).Map(func(s string) string {
+		return strings.ToUpper(s)
+	}).CollectChan()
+
+	var result strings.Builder
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range items {
+			result.WriteString(item.(string))
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if result.String() != "HELLOWORLD" {
+		t.Fatal("unexpected result:", result.String())
+	}
+}
+
+func TestStream_CollectChan_Error(t *testing.T) {
+	items, errs := New(nil).CollectChan()
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("expecting error for missing source")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if _, opened := <-items; opened {
+		t.Fatal("expecting items channel to be closed")
+	}
+}
+
+func TestStream_CollectChan_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case src <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	items, errs := New(src).WithContext(ctx).CollectChan()
+	<-items
+	cancel()
+
+	select {
+	case <-errs:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("stream not cancelled")
+	}
+}
+
+func TestStream_CollectSliceE(t *testing.T) {
+	items, errs, err := New(emitters.Slice([]int{1, 2, 3, 4})).
+		Map(func(i int) interface{} {
+			if i%2 == 0 {
+				return api.Error(fmt.Sprintf("even item %d", i))
+			}
+			return i
+		}).CollectSliceE()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0] != 1 || items[1] != 3 {
+		t.Fatal("unexpected items:", items)
+	}
+	if len(errs) != 2 || errs[1].Error() != "even item 4" {
+		t.Fatal("unexpected errors:", errs)
+	}
+}
+
+func TestStream_CollectSliceE_Error(t *testing.T) {
+	if _, _, err := New(nil).CollectSliceE(); err == nil {
+		t.Fatal("expecting error for missing source")
+	}
+}
+
+func TestStream_IntoChan(t *testing.T) {
+	out := make(chan string)
+	strm := New(emitters.Slice([]string{"a", "b", "c"})).Map(strings.ToUpper).IntoChan(out)
+	errCh := strm.Open()
+
+	var result []string
+	for item := range out {
+		result = append(result, item)
+	}
+	if strings.Join(result, "") != "ABC" {
+		t.Fatal("unexpected result:", result)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_IntoChan_Invalid(t *testing.T) {
+	var nilCh chan string
+	tests := []struct {
+		name string
+		ch   interface{}
+	}{
+		{"receive-only channel", make(<-chan string)},
+		{"nil channel", nilCh},
+	}
+
+	for _, test := range tests {
+		var seen int32
+		strm := New(emitters.Slice([]string{"a"})).
+			Tap(func(interface{}) { atomic.AddInt32(&seen, 1) }).
+			IntoChan(test.ch)
+		drain := strm.Open()
+		select {
+		case err := <-drain:
+			if err == nil {
+				t.Fatalf("%s: expecting error", test.name)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		select {
+		case err := <-drain:
+			t.Fatalf("%s: unexpected second error: %v", test.name, err)
+		case <-time.After(10 * time.Millisecond):
+		}
+		if n := atomic.LoadInt32(&seen); n != 0 {
+			t.Fatalf("%s: stream should not run, %d item(s) processed", test.name, n)
+		}
+	}
+}
+
+func TestStream_IntoByType(t *testing.T) {
+	type order struct{ ID int }
+	type refund struct{ ID int }
+
+	orders, refunds, others := collectors.Slice(), collectors.Slice(), collectors.Slice()
+	strm := New(emitters.Slice([]interface{}{order{1}, refund{2}, "noise", order{3}})).
+		IntoByType(map[reflect.Type]api.Sink{
+			reflect.TypeOf(order{}):  orders,
+			reflect.TypeOf(refund{}): refunds,
+		}, others)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(orders.Get()) != 2 || len(refunds.Get()) != 1 || len(others.Get()) != 1 {
+		t.Fatal("unexpected routing:", orders.Get(), refunds.Get(), others.Get())
+	}
+}
+
+func TestStream_IntoAll(t *testing.T) {
+	modes := []collectors.BroadcastMode{
+		collectors.BroadcastSequential,
+		collectors.BroadcastConcurrent,
+		collectors.BroadcastBuffered,
+	}
+	for _, mode := range modes {
+		snk1, snk2 := collectors.Slice(), collectors.Slice()
+		strm := New(emitters.Slice([]string{"a", "b", "c"})).
+			Map(strings.ToUpper).
+			WithConcurrentCollectors(mode).
+			IntoAll(snk1, snk2)
+
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		expected := []interface{}{"A", "B", "C"}
+		if !reflect.DeepEqual(snk1.Get(), expected) || !reflect.DeepEqual(snk2.Get(), expected) {
+			t.Fatalf("mode %d: unexpected result %v %v", mode, snk1.Get(), snk2.Get())
+		}
+	}
+}
+
+func TestStream_IntoAll_Error(t *testing.T) {
+	good := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b"})).IntoAll(good, collectors.Func(nil))
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error from failing sink")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if len(good.Get()) != 2 {
+		t.Fatal("unexpected result:", good.Get())
+	}
+}
+
+func TestStream_IntoAll_NoSinks(t *testing.T) {
+	var seen int32
+	strm := New(emitters.Slice([]string{"a", "b"})).
+		Tap(func(interface{}) { atomic.AddInt32(&seen, 1) }).
+		IntoAll()
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error without sinks")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if n := atomic.LoadInt32(&seen); n != 0 {
+		t.Fatalf("stream should not run, %d item(s) processed", n)
+	}
+}
+
+func TestStream_CollectInto(t *testing.T) {
+	buf := make([]interface{}, 0, 8)
+	buf = append(buf, "header")
+
+	err := New(emitters.Slice([]string{"a", "b"})).Map(strings.ToUpper).CollectInto(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(buf, []interface{}{"header", "A", "B"}) {
+		t.Fatal("unexpected result:", buf)
+	}
+	if cap(buf) != 8 {
+		t.Fatal("expecting buffer capacity to be reused, got", cap(buf))
+	}
+
+	if err := New(emitters.Slice([]string{"a"})).CollectInto(nil); err == nil {
+		t.Fatal("expecting error for nil slice pointer")
+	}
+}
+
+func TestStream_Drain(t *testing.T) {
+	var m sync.Mutex
+	var seen []string
+	errCount := 0
+	err := New(emitters.Slice([]string{"A", "B", "C"})).WithErrorFunc(func(err api.StreamError) {
+		m.Lock()
+		errCount++
+		m.Unlock()
+	}).Process(func(item string) error {
+		if item == "B" {
+			return api.Error("bad item")
+		}
+		m.Lock()
+		seen = append(seen, item)
+		m.Unlock()
+		return nil
+	}).Drain()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(seen) != 2 {
+		t.Fatal("unexpected side effects:", seen)
+	}
+	if errCount != 1 {
+		t.Fatal("expecting 1 error, got", errCount)
+	}
+}
+
+func TestStream_Drain_Error(t *testing.T) {
+	if err := New(nil).Drain(); err == nil {
+		t.Fatal("expecting error for missing source")
+	}
+}
+
+func TestStream_CountByKey(t *testing.T) {
+	counts, err := New(emitters.Slice([]string{"hello world", "hello automi"})).
+		FlatMap(func(line string) []string {
+			return strings.Split(line, " ")
+		}).CountByKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 3 || counts["hello"] != 2 || counts["world"] != 1 {
+		t.Fatal("unexpected counts:", counts)
+	}
+}
+
+func TestStream_GroupInto(t *testing.T) {
+	groups, err := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		Map(func(i int) int { return i * 10 }).
+		GroupInto(func(item interface{}) interface{} {
+			return item.(int)%20 == 0
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[interface{}][]interface{}{
+		true:  {20, 40, 60},
+		false: {10, 30, 50},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatal("unexpected groups:", groups)
+	}
+}
+
+func TestStream_GroupInto_Error(t *testing.T) {
+	groups, err := New(42).GroupInto(nil)
+	if err == nil {
+		t.Fatal("expecting error for invalid source")
+	}
+	if groups != nil {
+		t.Fatal("unexpected groups:", groups)
+	}
+}
+
+func TestStream_Replayable(t *testing.T) {
+	replay := collectors.Replayable()
+	if err := <-New([]int{1, 2, 3, 4}).Into(replay).Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := New(replay.Emitter()).CountByKey(func(item interface{}) interface{} {
+		return item.(int)%2 == 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum[true] != 2 || sum[false] != 2 {
+		t.Fatal("unexpected second pass result:", sum)
+	}
+}
+
+func TestStream_CollectMap(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	users := []user{{1, "ann"}, {2, "bob"}, {1, "amy"}}
+	keyFn := func(item interface{}) interface{} { return item.(user).ID }
+	valFn := func(item interface{}) interface{} { return item.(user).Name }
+
+	result, err := New(emitters.Slice(users)).CollectMap(keyFn, valFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[1] != "amy" || result[2] != "bob" {
+		t.Fatal("unexpected map:", result)
+	}
+
+	if _, err := New(emitters.Slice(users)).
+		CollectMapWith(keyFn, valFn, collectors.FailOnDuplicate); err == nil {
+		t.Fatal("expecting duplicate key error")
+	}
+}
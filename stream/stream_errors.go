@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/taiyang-li/automi/api"
+)
+
+// ErrorPolicy determines how a stream reacts to stream errors
+type ErrorPolicy int
+
+const (
+	// ContinueOnError reports stream errors to the error function
+	// and the stream keeps going (default).
+	ContinueOnError ErrorPolicy = iota
+
+	// FailOnError cancels the stream on the first stream error and
+	// returns that error from Open().
+	FailOnError
+)
+
+// ErrorSink captures a sample of the stream errors seen during a run
+// so they can be inspected once the stream completes.
+type ErrorSink struct {
+	max   int
+	errs  []api.StreamError
+	mutex sync.RWMutex
+}
+
+// Get returns the captured errors in the order they were reported
+func (e *ErrorSink) Get() []api.StreamError {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	result := make([]api.StreamError, len(e.errs))
+	copy(result, e.errs)
+	return result
+}
+
+// add captures err unless the sink is full
+func (e *ErrorSink) add(err api.StreamError) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.max > 0 && len(e.errs) >= e.max {
+		return
+	}
+	e.errs = append(e.errs, err)
+}
+
+// TapErrors returns an *ErrorSink that captures up to max stream errors
+// reported during the run.  Once max errors are captured, newer errors are
+// dropped from the sink (the first errors are kept).  A max value <= 0 captures
+// all errors.  Errors are still forwarded to the error function set with
+// WithErrorFunc.
+func (s *Stream) TapErrors(max int) *ErrorSink {
+	snk := &ErrorSink{max: max}
+	s.errSinks = append(s.errSinks, snk)
+	return snk
+}
+
+// IntoSplit sets two sinks for the stream: items that reach the end of
+// the stream are sent to the ok sink while stream errors (of type
+// api.StreamError, with their items attached if any) are sent to the errs
+// sink.  This is commonly used to produce a rejects file:
+//
+//   strm.IntoSplit(collectors.CSV(goodFile), collectors.Writer(rejectsFile))
+//
+// Both sinks are opened with the stream and closed when the stream completes.
+// Errors are still reported to the error function set with WithErrorFunc.
+func (s *Stream) IntoSplit(ok, errs api.Sink) *Stream {
+	s.errSnk = newSideSink(errs)
+	s.sideSinks = append(s.sideSinks, s.errSnk)
+	return s.Into(ok)
+}
+
+// WithDeadLetter sets a dead-letter sink for the stream: each stream error
+// that carries the failed item (i.e. reported with api.ErrorWithItem) is sent
+// to snk, as an api.StreamError, so the item can be recovered instead of being
+// dropped.  The failed items are no longer sent downstream by the operators
+// and errors without an item are not sent to snk.  It is typically used with
+// the dead-letter collector:
+//
+//   strm.WithDeadLetter(collectors.DeadLetter(rejectsFile))
+//
+// The sink is opened and closed along with the stream.  Errors are still
+// reported to the error function set with WithErrorFunc.
+func (s *Stream) WithDeadLetter(snk api.Sink) *Stream {
+	s.deadLetter = newSideSink(snk)
+	s.sideSinks = append(s.sideSinks, s.deadLetter)
+	return s
+}
+
+// setupDeadLetter stops the operators from sending the items of
+// stream errors downstream, since they are sent to the dead-letter sink
+func (s *Stream) setupDeadLetter() {
+	if s.deadLetter == nil {
+		return
+	}
+	for _, op := range s.ops {
+		if operator, ok := op.(interface{ SetDropErrorItems(bool) }); ok {
+			operator.SetDropErrorItems(true)
+		}
+	}
+}
+
+// sideSink is a sink, opened and closed along with the stream sink,
+// that receives the stream errors routed to it.
+type sideSink struct {
+	snk    api.Sink
+	output chan interface{}
+	done   <-chan error
+	closed bool
+	mutex  sync.Mutex
+}
+
+func newSideSink(snk api.Sink) *sideSink {
+	output := make(chan interface{}, 1024)
+	snk.SetInput(output)
+	return &sideSink{snk: snk, output: output}
+}
+
+// open opens the underlying sink
+func (ss *sideSink) open(ctx context.Context) {
+	ss.done = ss.snk.Open(ctx)
+}
+
+// send routes err to the sink unless the side sink is closed
+func (ss *sideSink) send(ctx context.Context, err api.StreamError) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.closed {
+		return
+	}
+	select {
+	case ss.output <- err:
+	case <-ctx.Done():
+	}
+}
+
+// close closes the sink input, errors sent after are discarded,
+// then waits for the underlying sink to complete.
+func (ss *sideSink) close() error {
+	ss.mutex.Lock()
+	if !ss.closed {
+		ss.closed = true
+		close(ss.output)
+	}
+	ss.mutex.Unlock()
+	if ss.done == nil {
+		return nil
+	}
+	return <-ss.done
+}
+
+// WithErrorPolicy sets the policy that determines how the stream reacts
+// to stream errors. Policies are mutually exclusive, the last one set wins.
+func (s *Stream) WithErrorPolicy(policy ErrorPolicy) *Stream {
+	s.errPolicy = policy
+	return s
+}
+
+// FailFast sets the error policy to FailOnError: the very first stream error
+// (of any kind) is reported to the error function, then the stream context
+// is cancelled which causes all components to stop.  The error is then returned
+// by Open().  This is typically used for batch jobs where any failure should
+// abort the run.  It replaces the default ContinueOnError policy.
+func (s *Stream) FailFast() *Stream {
+	return s.WithErrorPolicy(FailOnError)
+}
+
+// WithMaxErrors sets the number of stream errors, n, after which the stream
+// is aborted.  Errors are reported to the error function as usual, but when
+// the n-th error is reported the stream context is cancelled, which causes all
+// components to stop, and Open() returns an error summarizing the failure.
+// This is typically used for batch jobs where a high failure rate means the
+// run should be abandoned early.  A value of n <= 0 disables the threshold.
+func (s *Stream) WithMaxErrors(n int) *Stream {
+	s.maxErrors = int64(n)
+	return s
+}
+
+// ErrorCount returns the number of stream errors reported so far by the
+// stream components.  It is safe to call while the stream is running, for
+// instance from a supervising goroutine monitoring the error rate.
+func (s *Stream) ErrorCount() int64 {
+	return atomic.LoadInt64(&s.errCount)
+}
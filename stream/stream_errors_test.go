@@ -0,0 +1,279 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_TapErrors(t *testing.T) {
+	count := 0
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		WithErrorFunc(func(err api.StreamError) {
+			count++
+		}).
+		Process(func(i int) interface{} {
+			if i%2 == 0 {
+				return api.ErrorWithItem("even number", &api.StreamItem{Item: i})
+			}
+			return i
+		}).Into(collectors.Null())
+	errs := strm.TapErrors(2)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	captured := errs.Get()
+	if len(captured) != 2 {
+		t.Fatal("expecting 2 captured errors, got", len(captured))
+	}
+	if captured[0].Item().Item != 2 || captured[1].Item().Item != 4 {
+		t.Fatal("unexpected captured errors:", captured)
+	}
+	if count != 3 {
+		t.Fatal("errors not forwarded to error func, got", count)
+	}
+	if strm.ErrorCount() != 3 {
+		t.Fatal("expecting error count of 3, got", strm.ErrorCount())
+	}
+}
+
+func TestStream_ErrorCount_WhileRunning(t *testing.T) {
+	src := make(chan int)
+	strm := New(src).
+		Process(func(i int) interface{} {
+			return api.Error("failed")
+		}).Into(collectors.Null())
+
+	errCh := strm.Open()
+	for i := 0; i < 5; i++ {
+		src <- i
+	}
+
+	// poll the counter, as a supervisor would, before the stream ends
+	deadline := time.After(50 * time.Millisecond)
+	for strm.ErrorCount() < 5 {
+		select {
+		case <-deadline:
+			t.Fatal("expecting error count of 5, got", strm.ErrorCount())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	close(src)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_FailFast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case src <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	count := 0
+	strm := New(src).FailFast().
+		WithErrorFunc(func(err api.StreamError) {
+			count++
+		}).
+		Process(func(i int) interface{} {
+			if i == 5 {
+				return api.Error("bad item 5")
+			}
+			return i
+		}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil || err.Error() != "bad item 5" {
+			t.Fatal("expecting first error from Open, got", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if count != 1 {
+		t.Fatal("expecting one error reported, got", count)
+	}
+}
+
+func TestStream_IntoSplit(t *testing.T) {
+	type event struct {
+		Name string `json:"name"`
+	}
+	ok := collectors.Slice()
+	rejects := collectors.Slice()
+	strm := New(emitters.Slice([]string{`{"name":"a"}`, `{bad`, `{"name":"b"}`, `}`})).
+		ParseJSON(event{}).
+		Map(func(e event) string {
+			return e.Name
+		}).IntoSplit(ok, rejects)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(ok.Get()) != 2 || ok.Get()[0] != "a" || ok.Get()[1] != "b" {
+		t.Fatal("unexpected ok items:", ok.Get())
+	}
+	if len(rejects.Get()) != 2 {
+		t.Fatal("unexpected rejects:", rejects.Get())
+	}
+	for i, expected := range []string{`{bad`, `}`} {
+		reject := rejects.Get()[i].(api.StreamError)
+		if reject.Item().Item != expected {
+			t.Fatal("unexpected reject item:", reject.Item().Item)
+		}
+	}
+}
+
+func TestStream_WithDeadLetter(t *testing.T) {
+	var dead bytes.Buffer
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, -2, 3, -4})).
+		WithDeadLetter(collectors.DeadLetter(&dead)).
+		Process(func(i int) interface{} {
+			if i < 0 {
+				return api.ErrorWithItem("negative value", &api.StreamItem{Item: i})
+			}
+			if i == 3 {
+				return api.Error("no item attached")
+			}
+			return i
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 1 || snk.Get()[0] != 1 {
+		t.Fatal("unexpected collected items:", snk.Get())
+	}
+	expected := "{\"error\":\"negative value\",\"item\":-2}\n" +
+		"{\"error\":\"negative value\",\"item\":-4}\n"
+	if dead.String() != expected {
+		t.Fatalf("unexpected dead letters %q", dead.String())
+	}
+}
+
+func TestStream_ErrorCause(t *testing.T) {
+	errOdd := errors.New("odd value")
+	matched := 0
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		WithErrorFunc(func(err api.StreamError) {
+			if errors.Is(err, errOdd) {
+				matched++
+			}
+		}).
+		Process(func(i int) interface{} {
+			if i%2 != 0 {
+				return fmt.Errorf("item %d: %w", i, errOdd)
+			}
+			return i
+		}).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if matched != 2 {
+		t.Fatal("expecting 2 errors matching cause, got", matched)
+	}
+}
+
+func TestStream_WithMaxErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case src <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	strm := New(src).WithMaxErrors(3).
+		Process(func(i int) interface{} {
+			if i%2 == 0 {
+				return api.Error(fmt.Sprintf("bad item %d", i))
+			}
+			return i
+		}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		expected := "stream aborted after 3 errors, last error: bad item 4"
+		if err == nil || err.Error() != expected {
+			t.Fatal("expecting summary error from Open, got", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if strm.ErrorCount() < 3 {
+		t.Fatal("expecting at least 3 errors, got", strm.ErrorCount())
+	}
+}
+
+func TestStream_WithMaxErrors_BelowThreshold(t *testing.T) {
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).WithMaxErrors(3).
+		Process(func(i int) interface{} {
+			if i%2 == 0 {
+				return api.Error("bad item")
+			}
+			return i
+		}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
@@ -102,3 +102,42 @@ func TestStream_Log_With_Logger(t *testing.T) {
 		t.Fatal("logger func not logging properly")
 	}
 }
+
+func TestStream_Log_WithName(t *testing.T) {
+	var lock sync.Mutex
+	var logs []string
+
+	strm := New(emitters.Slice([]string{"hello", "world"})).
+		WithName("greeter").
+		WithLogFunc(func(val interface{}) {
+			lock.Lock()
+			logs = append(logs, val.(string))
+			lock.Unlock()
+		})
+	strm.Process(func(ctx context.Context, val string) string {
+		if name := autoctx.GetStreamName(ctx); name != "greeter" {
+			t.Error("unexpected stream name in context:", name)
+		}
+		return val
+	}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(logs) < 2 {
+		t.Fatal("stream not logging")
+	}
+	for _, line := range logs {
+		if !strings.HasPrefix(line, "greeter: ") {
+			t.Fatal("log event not tagged with stream name:", line)
+		}
+	}
+}
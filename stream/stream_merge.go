@@ -0,0 +1,381 @@
+package stream
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/api/tuple"
+	"github.com/taiyang-li/automi/util"
+)
+
+// MergeSorted returns a new *Stream that merges the items of the provided
+// streams in a globally sorted order, using function less to compare items.
+// Each input stream is expected to be individually sorted using the
+// same ordering (i.e. sorted files) as the merge is done with a k-way merge
+// over the heads of the input streams.  Inputs can be of different lengths,
+// the merge completes when all inputs are exhausted.
+//
+// Errors from the input streams are reported to the error function of
+// the returned stream.  When an input stream fails, the returned stream is
+// aborted and its Open returns the error of the input.
+func MergeSorted(less func(a, b interface{}) bool, streams ...*Stream) *Stream {
+	m := &sortedMerger{
+		less:    less,
+		streams: streams,
+		output:  make(chan interface{}, 1024),
+	}
+	strm := New(m)
+	m.abort = strm.fail
+	return strm
+}
+
+// mergeHead is the current item for input stream at index src
+type mergeHead struct {
+	item interface{}
+	src  int
+}
+
+// mergeHeap is a min-heap of input heads used for k-way merge
+type mergeHeap struct {
+	heads []mergeHead
+	less  func(a, b interface{}) bool
+}
+
+func (h *mergeHeap) Len() int { return len(h.heads) }
+func (h *mergeHeap) Less(i, j int) bool {
+	return h.less(h.heads[i].item, h.heads[j].item)
+}
+func (h *mergeHeap) Swap(i, j int)      { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+func (h *mergeHeap) Push(x interface{}) { h.heads = append(h.heads, x.(mergeHead)) }
+func (h *mergeHeap) Pop() interface{} {
+	last := len(h.heads) - 1
+	head := h.heads[last]
+	h.heads = h.heads[:last]
+	return head
+}
+
+// sortedMerger is an emitter that does a k-way merge of sorted streams
+type sortedMerger struct {
+	less    func(a, b interface{}) bool
+	streams []*Stream
+	abort   func(error)
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// GetOutput returns the output channel of this source node
+func (m *sortedMerger) GetOutput() <-chan interface{} {
+	return m.output
+}
+
+// Open opens all input streams and starts merging them
+func (m *sortedMerger) Open(ctx context.Context) error {
+	if m.less == nil {
+		return errors.New("sorted merge missing less function")
+	}
+	m.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(m.logf, "Opening sorted merge emitter")
+
+	inputs := make([]<-chan interface{}, len(m.streams))
+	for i, strm := range m.streams {
+		src := newStreamSource(strm, m.abort)
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+		inputs[i] = src.GetOutput()
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(m.logf, "Closing sorted merge emitter")
+			close(m.output)
+		}()
+
+		// next reads the next head from input i
+		next := func(i int) (mergeHead, bool) {
+			select {
+			case item, opened := <-inputs[i]:
+				return mergeHead{item: item, src: i}, opened
+			case <-ctx.Done():
+				return mergeHead{}, false
+			}
+		}
+
+		h := &mergeHeap{less: m.less}
+		for i := range inputs {
+			if head, ok := next(i); ok {
+				h.heads = append(h.heads, head)
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			head := heap.Pop(h).(mergeHead)
+			select {
+			case m.output <- head.item:
+			case <-ctx.Done():
+				return
+			}
+			if head, ok := next(head.src); ok {
+				heap.Push(h, head)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Interleave returns a new *Stream that strictly round-robins one item
+// from each of the provided streams in turn.  When it is the turn of a
+// stream that has no item ready, the interleave blocks on that stream rather
+// than skipping it, giving a fair and deterministic interleaving. Streams
+// that complete are removed from the rotation, the interleave completes when
+// all streams are exhausted.
+//
+// Errors from the input streams are reported to the error function of
+// the returned stream.  When an input stream fails, the returned stream is
+// aborted and its Open returns the error of the input.
+func Interleave(streams ...*Stream) *Stream {
+	m := &interleaver{
+		streams: streams,
+		output:  make(chan interface{}, 1024),
+	}
+	strm := New(m)
+	m.abort = strm.fail
+	return strm
+}
+
+// interleaver is an emitter that round-robins items from streams
+type interleaver struct {
+	streams []*Stream
+	abort   func(error)
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// GetOutput returns the output channel of this source node
+func (m *interleaver) GetOutput() <-chan interface{} {
+	return m.output
+}
+
+// Open opens all input streams and starts interleaving them
+func (m *interleaver) Open(ctx context.Context) error {
+	m.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(m.logf, "Opening interleave emitter")
+
+	inputs := make([]<-chan interface{}, len(m.streams))
+	for i, strm := range m.streams {
+		src := newStreamSource(strm, m.abort)
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+		inputs[i] = src.GetOutput()
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(m.logf, "Closing interleave emitter")
+			close(m.output)
+		}()
+
+		for len(inputs) > 0 {
+			active := inputs[:0]
+			for _, input := range inputs {
+				select {
+				case item, opened := <-input:
+					if !opened {
+						continue // remove from rotation
+					}
+					active = append(active, input)
+					select {
+					case m.output <- item:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+			inputs = active
+		}
+	}()
+
+	return nil
+}
+
+// Merge returns a new *Stream that multiplexes the items of the provided
+// streams into a single stream.  Items are emitted as soon as they are
+// available from any of the inputs, so ordering across inputs is not
+// deterministic. The merge completes when all inputs are exhausted.
+//
+// Input streams inherit the context of the returned stream (unless already
+// set), cancelling it tears down all inputs.  Errors from the input streams
+// are reported to the error function of the returned stream.  When an input
+// stream fails, the returned stream is aborted and its Open returns the error
+// of the input.
+func Merge(streams ...*Stream) *Stream {
+	m := &merger{
+		streams: streams,
+		output:  make(chan interface{}, 1024),
+	}
+	strm := New(m)
+	m.abort = strm.fail
+	return strm
+}
+
+// merger is an emitter that fans in items from streams
+type merger struct {
+	streams []*Stream
+	abort   func(error)
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// GetOutput returns the output channel of this source node
+func (m *merger) GetOutput() <-chan interface{} {
+	return m.output
+}
+
+// Open opens all input streams and starts merging them
+func (m *merger) Open(ctx context.Context) error {
+	m.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(m.logf, "Opening merge emitter")
+
+	inputs := make([]<-chan interface{}, len(m.streams))
+	for i, strm := range m.streams {
+		src := newStreamSource(strm, m.abort)
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+		inputs[i] = src.GetOutput()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(inputs))
+	for _, input := range inputs {
+		go func(input <-chan interface{}) {
+			defer wg.Done()
+			for {
+				select {
+				case item, opened := <-input:
+					if !opened {
+						return
+					}
+					select {
+					case m.output <- item:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(input)
+	}
+
+	go func() {
+		wg.Wait()
+		util.Logfn(m.logf, "Closing merge emitter")
+		close(m.output)
+	}()
+
+	return nil
+}
+
+// Zip returns a new *Stream that pairs the items of streams a and b
+// by position, emitting a tuple.KV{aItem, bItem} for each step.  Zip stops
+// emitting when either stream is exhausted; the remaining items of the
+// longer stream are drained and discarded so that it can complete.
+// Emitters can be zipped by wrapping them with New (i.e. Zip(New(e1), New(e2))).
+//
+// Errors from the input streams are reported to the error function of
+// the returned stream.  When an input stream fails, the returned stream is
+// aborted and its Open returns the error of the input.
+func Zip(a, b *Stream) *Stream {
+	z := &zipper{
+		streams: [2]*Stream{a, b},
+		output:  make(chan interface{}, 1024),
+	}
+	strm := New(z)
+	z.abort = strm.fail
+	return strm
+}
+
+// zipper is an emitter that pairs items from two streams
+type zipper struct {
+	streams [2]*Stream
+	abort   func(error)
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// GetOutput returns the output channel of this source node
+func (z *zipper) GetOutput() <-chan interface{} {
+	return z.output
+}
+
+// Open opens both input streams and starts pairing their items
+func (z *zipper) Open(ctx context.Context) error {
+	z.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(z.logf, "Opening zip emitter")
+
+	var inputs [2]<-chan interface{}
+	for i, strm := range z.streams {
+		src := newStreamSource(strm, z.abort)
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+		inputs[i] = src.GetOutput()
+	}
+
+	// drain discards the remaining items of input
+	drain := func(input <-chan interface{}) {
+		for {
+			select {
+			case _, opened := <-input:
+				if !opened {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(z.logf, "Closing zip emitter")
+			close(z.output)
+		}()
+
+		for {
+			var pair tuple.KV
+			for i, input := range inputs {
+				select {
+				case item, opened := <-input:
+					if !opened {
+						// no more pairs: complete now, the other input
+						// may never close, then drain it until cancelled
+						util.Logfn(z.logf, "Zip input exhausted, draining remaining input")
+						go drain(inputs[1-i])
+						return
+					}
+					pair[i] = item
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case z.output <- pair:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api/tuple"
+	"github.com/taiyang-li/automi/collectors"
+)
+
+func TestStream_MergeSorted(t *testing.T) {
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+	snk := collectors.Slice()
+	strm := MergeSorted(less,
+		New([]int{1, 4, 7, 10, 11, 12}),
+		New([]int{2, 5, 8}),
+		New([]int{}),
+		New([]int{0, 3, 6, 9}),
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	var result []int
+	for _, item := range snk.Get() {
+		result = append(result, item.(int))
+	}
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatal("unexpected merge result:", result)
+	}
+}
+
+func TestStream_MergeSorted_InputError(t *testing.T) {
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+	snk := collectors.Slice()
+	strm := MergeSorted(less, New([]int{1, 2}), New(nil)).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting input error")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_Merge_InputFailure(t *testing.T) {
+	errBad := errors.New("bad input")
+	failing := func() *Stream {
+		return New([]int{1}).WithErrorPolicy(FailOnError).Map(func(i int) interface{} {
+			return errBad
+		})
+	}
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+
+	tests := []struct {
+		name string
+		strm *Stream
+	}{
+		{"MergeSorted", MergeSorted(less, New([]int{1, 2}), failing())},
+		{"Interleave", Interleave(New([]int{1, 2}), failing())},
+		{"Merge", Merge(New([]int{1, 2}), failing())},
+		{"Zip", Zip(New([]int{1, 2}), failing())},
+	}
+
+	for _, test := range tests {
+		select {
+		case err := <-test.strm.Into(collectors.Null()).Open():
+			if !errors.Is(err, errBad) {
+				t.Fatalf("%s: expecting input failure, got %v", test.name, err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+	}
+}
+
+func TestStream_Interleave(t *testing.T) {
+	snk := collectors.Slice()
+	strm := Interleave(
+		New([]string{"a1", "a2", "a3"}),
+		New([]string{"b1"}),
+		New([]string{"c1", "c2"}),
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"a1", "b1", "c1", "a2", "c2", "a3"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected interleave result:", snk.Get())
+	}
+}
+
+func TestStream_Merge(t *testing.T) {
+	snk := collectors.Slice()
+	strm := Merge(
+		New([]int{1, 2, 3}),
+		New([]int{}),
+		New([]int{4, 5}),
+		New([]int{6}),
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	var result []int
+	for _, item := range snk.Get() {
+		result = append(result, item.(int))
+	}
+	sort.Ints(result)
+	expected := []int{1, 2, 3, 4, 5, 6}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatal("unexpected merge result:", result)
+	}
+}
+
+func TestStream_Merge_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+	defer close(src)
+
+	strm := Merge(New(src), New([]int{1, 2})).WithContext(ctx).Into(collectors.Null())
+
+	errs := strm.Open()
+	cancel()
+	select {
+	case <-errs:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("merge not torn down on cancel")
+	}
+}
+
+func TestStream_Zip(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     *Stream
+		expected []interface{}
+	}{
+		{
+			name: "same length",
+			a:    New([]int{1, 2, 3}),
+			b:    New([]string{"a", "b", "c"}),
+			expected: []interface{}{
+				tuple.KV{1, "a"}, tuple.KV{2, "b"}, tuple.KV{3, "c"},
+			},
+		},
+		{
+			name:     "shorter first",
+			a:        New([]int{1}),
+			b:        New([]string{"a", "b", "c"}),
+			expected: []interface{}{tuple.KV{1, "a"}},
+		},
+		{
+			name:     "shorter second",
+			a:        New([]int{1, 2, 3}),
+			b:        New([]string{"a", "b"}),
+			expected: []interface{}{tuple.KV{1, "a"}, tuple.KV{2, "b"}},
+		},
+		{
+			name: "empty",
+			a:    New([]int{}),
+			b:    New([]string{"a", "b"}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snk := collectors.Slice()
+			strm := Zip(test.a, test.b).Into(snk)
+			select {
+			case err := <-strm.Open():
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(100 * time.Millisecond):
+				t.Fatal("Took too long")
+			}
+			if len(snk.Get()) == 0 && len(test.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(snk.Get(), test.expected) {
+				t.Fatal("unexpected zip result:", snk.Get())
+			}
+		})
+	}
+}
+
+func TestStream_Zip_Unbounded(t *testing.T) {
+	endless := make(chan int)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case endless <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	snk := collectors.Slice()
+	strm := Zip(New([]string{"a", "b"}), New(endless)).Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Zip did not complete with a never-closing input")
+	}
+
+	if len(snk.Get()) != 2 || snk.Get()[0].(tuple.KV)[0] != "a" || snk.Get()[1].(tuple.KV)[0] != "b" {
+		t.Fatal("unexpected zip result:", snk.Get())
+	}
+}
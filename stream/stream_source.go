@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"context"
+	"errors"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// streamSource is an emitter that sources its data from the
+// result of another stream.  It allows a *Stream to be used as the
+// source of other streams (i.e. when combining streams).
+type streamSource struct {
+	strm   *Stream
+	abort  func(error)
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// newStreamSource returns a *streamSource that emits items
+// that reach the end of stream strm.  When strm fails, the abort
+// function is invoked with its error.
+func newStreamSource(strm *Stream, abort func(error)) *streamSource {
+	return &streamSource{
+		strm:   strm,
+		abort:  abort,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (e *streamSource) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the upstream and starts emitting its items. Unless
+// already set, the upstream inherits the context, the log and
+// error functions of the downstream.  Terminal errors from the
+// upstream abort the downstream.
+func (e *streamSource) Open(ctx context.Context) error {
+	if e.strm == nil {
+		return errors.New("stream emitter missing stream")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Opening stream emitter")
+
+	if e.strm.ctx == nil {
+		e.strm.WithContext(ctx)
+	}
+	if e.strm.logf == nil {
+		e.strm.WithLogFunc(e.logf)
+	}
+	if e.strm.errf == nil {
+		e.strm.WithErrorFunc(e.errf)
+	}
+
+	items, errs := e.strm.CollectChan()
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "Closing stream emitter")
+			close(e.output)
+		}()
+
+	loop:
+		for {
+			select {
+			case item, opened := <-items:
+				if !opened {
+					break loop
+				}
+				select {
+				case e.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := <-errs; err != nil {
+			util.Logfn(e.logf, err)
+			e.abort(err)
+		}
+	}()
+
+	return nil
+}
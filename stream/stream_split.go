@@ -0,0 +1,265 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// splitter is a sink that routes the items of a stream to the
+// outputs of several branch streams.  The route function returns the
+// indexes of the outputs to which an item is sent.
+type splitter struct {
+	strm      *Stream
+	route     func(item interface{}) []int
+	input     <-chan interface{}
+	outputs   []chan interface{}
+	logf      api.LogFunc
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// newSplitter creates a *splitter with n outputs for stream strm
+func newSplitter(strm *Stream, n int, route func(interface{}) []int) *splitter {
+	outputs := make([]chan interface{}, n)
+	for i := range outputs {
+		outputs[i] = make(chan interface{}, 1024)
+	}
+	return &splitter{
+		strm:    strm,
+		route:   route,
+		outputs: outputs,
+		done:    make(chan struct{}),
+	}
+}
+
+// SetInput sets the channel input
+func (sp *splitter) SetInput(in <-chan interface{}) {
+	sp.input = in
+}
+
+// Open opens the sink node to start routing items to outputs
+func (sp *splitter) Open(ctx context.Context) <-chan error {
+	sp.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(sp.logf, "Opening splitter")
+	result := make(chan error)
+
+	go func() {
+		defer func() {
+			util.Logfn(sp.logf, "Closing splitter")
+			sp.closeOutputs()
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-sp.input:
+				if !opened {
+					return
+				}
+				for _, i := range sp.route(item) {
+					select {
+					case sp.outputs[i] <- item:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result
+}
+
+// start opens the upstream once, when the first branch is opened.
+// Unless already set, the upstream inherits the context, the log and error
+// functions of the first opened branch.
+func (sp *splitter) start(ctx context.Context) {
+	sp.startOnce.Do(func() {
+		if sp.strm.ctx == nil {
+			sp.strm.WithContext(ctx)
+		}
+		if sp.strm.logf == nil {
+			sp.strm.WithLogFunc(autoctx.GetLogFunc(ctx))
+		}
+		if sp.strm.errf == nil {
+			sp.strm.WithErrorFunc(autoctx.GetErrFunc(ctx))
+		}
+		sp.strm.Into(sp)
+		errs := sp.strm.Open()
+		go func() {
+			sp.err = <-errs
+			// stream may fail before splitter is opened
+			sp.closeOutputs()
+			close(sp.done)
+		}()
+	})
+}
+
+func (sp *splitter) closeOutputs() {
+	sp.closeOnce.Do(func() {
+		for _, output := range sp.outputs {
+			close(output)
+		}
+	})
+}
+
+// branchSource is an emitter that sources its data from one
+// of the outputs of a splitter.
+type branchSource struct {
+	split  *splitter
+	index  int
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// GetOutput returns the output channel of this source node
+func (b *branchSource) GetOutput() <-chan interface{} {
+	return b.output
+}
+
+// Open starts the upstream, if not started, then emits items routed
+// to this branch.  The terminal error of the upstream, if any, is
+// reported to the error function of each branch.
+func (b *branchSource) Open(ctx context.Context) error {
+	if b.split == nil {
+		return errors.New("branch missing upstream")
+	}
+	b.logf = autoctx.GetLogFunc(ctx)
+	b.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(b.logf, "Opening branch emitter")
+
+	b.split.start(ctx)
+	input := b.split.outputs[b.index]
+
+	go func() {
+		defer func() {
+			util.Logfn(b.logf, "Closing branch emitter")
+			close(b.output)
+		}()
+
+	loop:
+		for {
+			select {
+			case item, opened := <-input:
+				if !opened {
+					break loop
+				}
+				select {
+				case b.output <- item:
+				case <-ctx.Done():
+					b.discard(input)
+					return
+				}
+			case <-ctx.Done():
+				b.discard(input)
+				return
+			}
+		}
+
+		<-b.split.done
+		if err := b.split.err; err != nil {
+			util.Logfn(b.logf, err)
+			autoctx.Err(b.errf, api.Error(err.Error()))
+		}
+	}()
+	return nil
+}
+
+// discard drains the branch input so that a cancelled branch
+// does not block the upstream or the other branches.
+func (b *branchSource) discard(input <-chan interface{}) {
+	go func() {
+		for range input {
+		}
+	}()
+}
+
+// split routes the items of the stream to n new branch streams
+// using the route function. All returned streams must be opened,
+// otherwise the upstream blocks once the branch buffer is full.
+func (s *Stream) split(n int, route func(interface{}) []int) []*Stream {
+	sp := newSplitter(s, n, route)
+	branches := make([]*Stream, n)
+	for i := range branches {
+		branches[i] = New(&branchSource{
+			split:  sp,
+			index:  i,
+			output: make(chan interface{}, 1024),
+		})
+	}
+	return branches
+}
+
+// ShardBy routes each item of the stream to one of n returned streams based
+// on the hash of the key computed by keyFn (hash(key) % n).  All items with the
+// same key are guaranteed to land in the same shard, so each shard can be
+// processed independently (i.e. keyed aggregation).
+//
+// The upstream starts when the first shard is opened. All shards must be opened
+// since a shard that is not consumed eventually blocks the others.  All shards
+// complete when the upstream completes and an upstream error is reported to
+// the error function of each shard.  Keys are hashed with the seed set
+// with WithHashSeed on the upstream.
+func (s *Stream) ShardBy(n int, keyFn func(interface{}) interface{}) []*Stream {
+	if n < 1 {
+		n = 1
+	}
+	indexes := make([][]int, n)
+	for i := range indexes {
+		indexes[i] = []int{i}
+	}
+	return s.split(n, func(item interface{}) []int {
+		seed := autoctx.GetHashSeed(s.ctx)
+		return indexes[util.HashKeySeed(keyFn(item), seed)%uint64(n)]
+	})
+}
+
+// Tee returns two streams that each receive every item of the stream, so
+// the same data can be sent to two different sinks.  Items are shared by
+// reference between both branches and must not be mutated by either branch
+// while the other may still access them.
+//
+// The upstream starts when the first branch is opened.  Both branches must be
+// opened, a slow branch applies backpressure to the upstream (and so to the
+// other branch) once its buffer is full.  Both branches complete when the
+// upstream completes and an upstream error is reported to the error function
+// of each branch.
+func (s *Stream) Tee() (*Stream, *Stream) {
+	both := []int{0, 1}
+	branches := s.split(2, func(interface{}) []int {
+		return both
+	})
+	return branches[0], branches[1]
+}
+
+// Partition routes the items of the stream that satisfy predicate to the
+// matched stream and the other items to the unmatched stream.  Unlike Filter,
+// rejected items remain available for processing, for instance:
+//
+//   valid, invalid := strm.Partition(isValid)
+//   valid.Into(dbSink)
+//   invalid.Into(deadLetterSink)
+//
+// The upstream starts when the first branch is opened.  Both branches must be
+// opened, since a branch that is not consumed eventually blocks the other.  Both
+// branches complete when the upstream completes or its context is cancelled,
+// and an upstream error is reported to the error function of each branch.
+func (s *Stream) Partition(predicate func(interface{}) bool) (matched, unmatched *Stream) {
+	routes := [][]int{{0}, {1}}
+	branches := s.split(2, func(item interface{}) []int {
+		if predicate(item) {
+			return routes[0]
+		}
+		return routes[1]
+	})
+	return branches[0], branches[1]
+}
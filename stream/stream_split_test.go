@@ -0,0 +1,244 @@
+package stream
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+	"github.com/taiyang-li/automi/util"
+)
+
+func TestStream_ShardBy(t *testing.T) {
+	words := []string{"a", "b", "c", "a", "d", "b", "e", "a", "f", "c"}
+	shards := New(emitters.Slice(words)).ShardBy(3, func(item interface{}) interface{} {
+		return item
+	})
+	if len(shards) != 3 {
+		t.Fatal("unexpected shard count:", len(shards))
+	}
+
+	sinks := make([]*collectors.SliceCollector, len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		sinks[i] = collectors.Slice()
+		wg.Add(1)
+		go func(strm *Stream) {
+			defer wg.Done()
+			if err := <-strm.Open(); err != nil {
+				t.Error(err)
+			}
+		}(shard.Into(sinks[i]))
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	total := 0
+	placement := make(map[interface{}]int)
+	for i, snk := range sinks {
+		for _, item := range snk.Get() {
+			total++
+			if shard, ok := placement[item]; ok && shard != i {
+				t.Fatal("key", item, "found in shards", shard, "and", i)
+			}
+			placement[item] = i
+		}
+	}
+	if total != len(words) {
+		t.Fatal("expecting", len(words), "items, got", total)
+	}
+}
+
+func TestStream_ShardBy_Error(t *testing.T) {
+	shards := New(nil).ShardBy(2, func(item interface{}) interface{} {
+		return item
+	})
+
+	var m sync.Mutex
+	errCount := 0
+	var wg sync.WaitGroup
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(strm *Stream) {
+			defer wg.Done()
+			<-strm.WithErrorFunc(func(api.StreamError) {
+				m.Lock()
+				errCount++
+				m.Unlock()
+			}).Open()
+		}(shard)
+	}
+	wg.Wait()
+
+	m.Lock()
+	defer m.Unlock()
+	if errCount != 2 {
+		t.Fatal("expecting upstream error reported to all shards, got", errCount)
+	}
+}
+
+func TestStream_ShardBy_WithHashSeed(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	placement := func(seed uint64) map[interface{}]int {
+		shards := New(emitters.Slice(keys)).WithHashSeed(seed).ShardBy(4, func(item interface{}) interface{} {
+			return item
+		})
+		sinks := make([]*collectors.SliceCollector, len(shards))
+		var wg sync.WaitGroup
+		for i, shard := range shards {
+			sinks[i] = collectors.Slice()
+			wg.Add(1)
+			go func(strm *Stream) {
+				defer wg.Done()
+				if err := <-strm.Open(); err != nil {
+					t.Error(err)
+				}
+			}(shard.Into(sinks[i]))
+		}
+		wg.Wait()
+
+		result := make(map[interface{}]int)
+		for i, snk := range sinks {
+			for _, item := range snk.Get() {
+				result[item] = i
+			}
+		}
+		return result
+	}
+
+	seeded := placement(42)
+	for _, key := range keys {
+		if expected := int(util.HashKeySeed(key, 42) % 4); seeded[key] != expected {
+			t.Fatalf("key %s: expecting shard %d, got %d", key, expected, seeded[key])
+		}
+	}
+	if !reflect.DeepEqual(seeded, placement(42)) {
+		t.Fatal("seeded placement not reproducible")
+	}
+	if reflect.DeepEqual(seeded, placement(0)) {
+		t.Fatal("expecting seed to change placement")
+	}
+}
+
+func TestStream_Tee(t *testing.T) {
+	words := []string{"a", "b", "c", "d"}
+	left, right := New(emitters.Slice(words)).Tee()
+
+	lsnk, rsnk := collectors.Slice(), collectors.Slice()
+	var wg sync.WaitGroup
+	for _, strm := range []*Stream{left.Into(lsnk), right.Map(strings.ToUpper).Into(rsnk)} {
+		wg.Add(1)
+		go func(strm *Stream) {
+			defer wg.Done()
+			if err := <-strm.Open(); err != nil {
+				t.Error(err)
+			}
+		}(strm)
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(lsnk.Get(), []interface{}{"a", "b", "c", "d"}) {
+		t.Fatal("unexpected left branch:", lsnk.Get())
+	}
+	if !reflect.DeepEqual(rsnk.Get(), []interface{}{"A", "B", "C", "D"}) {
+		t.Fatal("unexpected right branch:", rsnk.Get())
+	}
+}
+
+func TestStream_Partition(t *testing.T) {
+	matched, unmatched := New(emitters.Slice([]int{1, 2, 3, 4, 5})).Partition(func(item interface{}) bool {
+		return item.(int)%2 == 0
+	})
+
+	msnk, usnk := collectors.Slice(), collectors.Slice()
+	var wg sync.WaitGroup
+	for _, strm := range []*Stream{matched.Into(msnk), unmatched.Into(usnk)} {
+		wg.Add(1)
+		go func(strm *Stream) {
+			defer wg.Done()
+			if err := <-strm.Open(); err != nil {
+				t.Error(err)
+			}
+		}(strm)
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(wait)
+	}()
+	select {
+	case <-wait:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(msnk.Get(), []interface{}{2, 4}) {
+		t.Fatal("unexpected matched branch:", msnk.Get())
+	}
+	if !reflect.DeepEqual(usnk.Get(), []interface{}{1, 3, 5}) {
+		t.Fatal("unexpected unmatched branch:", usnk.Get())
+	}
+}
+
+func TestStream_Partition_Cancel(t *testing.T) {
+	src := make(chan int)
+	go func() {
+		defer close(src)
+		for i := 0; i < 100; i++ {
+			src <- i
+		}
+		time.Sleep(time.Second) // stalled source
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	matched, unmatched := New(src).WithContext(ctx).Partition(func(item interface{}) bool {
+		return item.(int) < 50
+	})
+
+	var wg sync.WaitGroup
+	for _, strm := range []*Stream{matched.Into(collectors.Null()), unmatched.Into(collectors.Null())} {
+		wg.Add(1)
+		go func(strm *Stream) {
+			defer wg.Done()
+			<-strm.Open()
+		}(strm)
+	}
+
+	wait := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(wait)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-wait:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("cancellation did not tear down both branches")
+	}
+}
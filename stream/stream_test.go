@@ -2,11 +2,17 @@ package stream
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/api/tuple"
+	"github.com/taiyang-li/automi/codec"
 	"github.com/taiyang-li/automi/collectors"
 	"github.com/taiyang-li/automi/emitters"
 )
@@ -21,6 +27,89 @@ func TestStream_New(t *testing.T) {
 	}
 }
 
+func TestStream_FromConstructors(t *testing.T) {
+	ch := make(chan string, 3)
+	ch <- "a"
+	ch <- "b"
+	ch <- "c"
+	close(ch)
+
+	tests := []struct {
+		name     string
+		strm     *Stream
+		expected string
+	}{
+		{"FromSlice", FromSlice([]string{"a", "b", "c"}), "ABC"},
+		{"FromChan", FromChan(ch), "ABC"},
+		{"FromReader", FromReader(strings.NewReader("abc")), "ABC"},
+	}
+
+	for _, test := range tests {
+		var result strings.Builder
+		strm := test.strm.Map(func(item interface{}) string {
+			switch val := item.(type) {
+			case []byte:
+				return strings.ToUpper(string(val))
+			case string:
+				return strings.ToUpper(val)
+			}
+			return ""
+		}).Into(collectors.Func(func(item interface{}) error {
+			result.WriteString(item.(string))
+			return nil
+		}))
+
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatalf("%s: %s", test.name, err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+		if result.String() != test.expected {
+			t.Fatalf("%s: unexpected result %s", test.name, result.String())
+		}
+	}
+}
+
+func TestStream_FromConstructors_Invalid(t *testing.T) {
+	var nilCh chan int
+	tests := []struct {
+		name string
+		strm *Stream
+	}{
+		{"FromSlice with map", FromSlice(map[string]int{"a": 1})},
+		{"FromSlice with nil", FromSlice(nil)},
+		{"FromChan with slice", FromChan([]int{1})},
+		{"FromChan with nil chan", FromChan(nilCh)},
+		{"FromChan with send-only chan", FromChan(make(chan<- int))},
+		{"FromReader with nil", FromReader(nil)},
+	}
+
+	for _, test := range tests {
+		var seen int32
+		drain := test.strm.Tap(func(interface{}) { atomic.AddInt32(&seen, 1) }).Open()
+		select {
+		case err := <-drain:
+			if err == nil {
+				t.Fatalf("%s: expecting error", test.name)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		select {
+		case err := <-drain:
+			t.Fatalf("%s: unexpected second error: %v", test.name, err)
+		case <-time.After(10 * time.Millisecond):
+		}
+		if n := atomic.LoadInt32(&seen); n != 0 {
+			t.Fatalf("%s: stream should not run, %d item(s) processed", test.name, n)
+		}
+	}
+}
+
 func TestStream_BuilderMethods(t *testing.T) {
 	op := func(ctx context.Context, data interface{}) interface{} {
 		return nil
@@ -41,6 +130,93 @@ func TestStream_BuilderMethods(t *testing.T) {
 	}
 }
 
+func TestStream_WithContext(t *testing.T) {
+	type ctxKey string
+	ctx := context.WithValue(context.Background(), ctxKey("tenant"), "acme")
+	tag := func(ctx context.Context, item string) string {
+		return ctx.Value(ctxKey("tenant")).(string) + ":" + item
+	}
+
+	// context set after the first operator is appended
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b"})).
+		Map(tag).
+		WithContext(ctx).
+		Map(strings.ToUpper).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"ACME:A", "ACME:B"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("context not propagated to operators:", snk.Get())
+	}
+}
+
+func TestStream_WithBufferSize(t *testing.T) {
+	strm := New([]int{1, 2, 3}).
+		Map(func(i int) int { return i }).
+		WithBufferSize(8).
+		Map(func(i int) int { return i }).
+		Batch().
+		ReStream().
+		Reduce(0, func(acc, i int) int { return acc + i })
+
+	expected := []int{1024, 8, 8, 8, 8}
+	for i, op := range strm.ops {
+		if size := cap(op.GetOutput()); size != expected[i] {
+			t.Fatalf("op %d: expecting buffer size %d, got %d", i, expected[i], size)
+		}
+	}
+
+	snk := collectors.Slice()
+	select {
+	case err := <-strm.Into(snk).Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if len(snk.Get()) != 1 || snk.Get()[0] != 6 {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_WithBufferSize_Operators(t *testing.T) {
+	identity := func(item interface{}) interface{} { return item }
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+	strm := New([]int{1, 2, 3}).
+		WithBufferSize(4).
+		Take(2).
+		Skip(1).
+		Throttle(10, time.Second).
+		Debounce(time.Millisecond).
+		Delay(time.Millisecond).
+		MapExpand(2, func(item interface{}, i int) interface{} { return item }).
+		GroupByFunc(func(i int) int { return i }).
+		MapParallel(identity, 2).
+		MapParallelKeyed(2, identity, identity).
+		SortExternal(less, 10, codec.Gob()).
+		BufferUntil(make(chan struct{}))
+
+	if len(strm.ops) != 11 {
+		t.Fatal("unexpected operator count:", len(strm.ops))
+	}
+	for i, op := range strm.ops {
+		if size := cap(op.GetOutput()); size != 4 {
+			t.Fatalf("op %d (%T): expecting buffer size 4, got %d", i, op, size)
+		}
+	}
+}
+
 func TestStream_InitGraph(t *testing.T) {
 	src := emitters.Slice([]string{"Hello", "World"})
 	snk := collectors.Slice()
@@ -116,3 +292,159 @@ func TestStream_Open_WithOp(t *testing.T) {
 	}
 	m.RUnlock()
 }
+
+func TestStream_WithPool(t *testing.T) {
+	var m sync.Mutex
+	created := 0
+	snk := collectors.Slice()
+	strm := New([]string{"hello", "world"}).WithPool(func() interface{} {
+		m.Lock()
+		created++
+		m.Unlock()
+		return new(strings.Builder)
+	}).Map(func(ctx context.Context, item string) string {
+		pool := autoctx.GetPool(ctx)
+		buf := pool.Get().(*strings.Builder)
+		defer pool.Put(buf)
+		buf.Reset()
+		buf.WriteString(strings.ToUpper(item))
+		return buf.String()
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0] != "HELLO" || result[1] != "WORLD" {
+		t.Fatal("unexpected result:", result)
+	}
+	m.Lock()
+	if created < 1 {
+		t.Fatal("pool never used")
+	}
+	m.Unlock()
+}
+
+func TestStream_WithMaxItems(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case src <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	strm := New(src).WithMaxItems(10).Map(func(i int) int {
+		return i * 2
+	}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error after max items exceeded")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_PrefetchSource(t *testing.T) {
+	src := make(chan int)
+	pulled := make(chan int, 10)
+	go func() {
+		defer close(src)
+		for i := 0; i < 10; i++ {
+			src <- i
+			pulled <- i
+		}
+	}()
+
+	release := make(chan struct{})
+	snk := collectors.Slice()
+	strm := New(src).PrefetchSource(5).Map(func(i int) int {
+		<-release
+		return i
+	}).Into(snk)
+
+	errCh := strm.Open()
+
+	// source must be drained, ahead of the blocked operator
+	for i := 0; i < 5; i++ {
+		select {
+		case <-pulled:
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("source not prefetched")
+		}
+	}
+	close(release)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snk.Get()) != 10 {
+			t.Fatal("unexpected result:", snk.Get())
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_WithMaxItems_WithinLimit(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]int{1, 2, 3}).WithMaxItems(3).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snk.Get()) != 3 {
+			t.Fatal("unexpected result:", snk.Get())
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_Flatten(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([][]string{{"a", "b"}, {}, {"c"}}).Flatten().Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"a", "b", "c"}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+
+	snk = collectors.Slice()
+	strm = New([]map[string]int{{"a": 1}}).Flatten().Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if !reflect.DeepEqual(snk.Get(), []interface{}{tuple.KV{"a", 1}}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
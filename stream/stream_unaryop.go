@@ -1,7 +1,11 @@
 package stream
 
 import (
+	"errors"
+	"time"
+
 	"github.com/taiyang-li/automi/api"
+	"github.com/taiyang-li/automi/codec"
 	"github.com/taiyang-li/automi/operators/unary"
 )
 
@@ -17,6 +21,51 @@ func (s *Stream) Transform(op api.UnOperation) *Stream {
 	return s
 }
 
+// Timeout sets the maximum duration allowed for the immediately preceding
+// operator to process a single item, for instance:
+//   strm.Map(fetch).Timeout(5*time.Second).Map(parse).Timeout(10*time.Millisecond)
+// When the duration expires, the context passed to the operator function is
+// cancelled and a StreamError is reported to the error function (the item is
+// not sent downstream).  Operator functions should honor the context
+// cancellation to avoid lingering goroutines.
+func (s *Stream) Timeout(d time.Duration) *Stream {
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("Timeout must follow an operator"))
+		return s
+	}
+	operator, ok := s.ops[len(s.ops)-1].(interface{ SetTimeout(time.Duration) })
+	if !ok {
+		s.drainErr(errors.New("Timeout not supported by preceding operator"))
+		return s
+	}
+	operator.SetTimeout(d)
+	return s
+}
+
+// AdaptiveParallel makes the immediately preceding operator adjust its
+// number of concurrent workers between min and max based on the backlog of
+// items waiting to be processed: a worker is added when the backlog stays high
+// and removed when it is empty.  This optimizes throughput without manual tuning
+// of WithConcurrency.  As with concurrent operators, the order of items is not
+// preserved.  Workers are shut down when the stream completes.
+func (s *Stream) AdaptiveParallel(min, max int) *Stream {
+	if min < 1 || max < min {
+		s.drainErr(errors.New("AdaptiveParallel requires 1 <= min <= max"))
+		return s
+	}
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("AdaptiveParallel must follow an operator"))
+		return s
+	}
+	operator, ok := s.ops[len(s.ops)-1].(interface{ SetAdaptiveConcurrency(int, int) })
+	if !ok {
+		s.drainErr(errors.New("AdaptiveParallel not supported by preceding operator"))
+		return s
+	}
+	operator.SetAdaptiveConcurrency(min, max)
+	return s
+}
+
 /*
 func (s *Stream) TransformWithConcurrency(op api.UnOperation, concurrency int) *Stream {
 	operator := unary.New()
@@ -55,6 +104,43 @@ func (s *Stream) Filter(f interface{}) *Stream {
 	return s.Transform(op)
 }
 
+// Compact removes nil items (including typed nils, such as a nil pointer)
+// and items with the zero value of their type (i.e. 0, "", empty struct)
+// from the stream.  Use CompactNil to only remove nil items.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#CompactFunc
+func (s *Stream) Compact() *Stream {
+	return s.Transform(unary.CompactFunc())
+}
+
+// CompactNil removes nil items, including typed nils, from the stream.
+// Unlike Compact, zero values are kept.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#CompactNilFunc
+func (s *Stream) CompactNil() *Stream {
+	return s.Transform(unary.CompactNilFunc())
+}
+
+// Tap invokes fn with each item, for side effects such as logging or metrics,
+// without transforming the stream: the item passes through unchanged.  A panic
+// in fn is recovered and reported to the error function, the item still
+// continues downstream.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#TapFunc
+func (s *Stream) Tap(fn func(item interface{})) *Stream {
+	if fn == nil {
+		s.drainErr(errors.New("Tap requires a function"))
+		return s
+	}
+	return s.Transform(unary.TapFunc(fn))
+}
+
 // Map uses the user-defined function to take the value of an incoming item and
 // returns a new value that is said to be mapped to the intial item.  The user-defined
 // function must be of type:
@@ -67,6 +153,40 @@ func (s *Stream) Map(f interface{}) *Stream {
 	return s.Transform(op)
 }
 
+// MapResult uses the user-defined function to map the value of an incoming item
+// in the manner of Go's comma-ok lookups.  The mapped value is only emitted
+// downstream when the returned flag is true, otherwise the item is dropped.
+// The user-defined function must be of type:
+//   func(T) (R, bool) - where T is the type of the incoming item and R the type of the returned item.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#MapResultFunc
+func (s *Stream) MapResult(f interface{}) *Stream {
+	op, err := unary.MapResultFunc(f)
+	if err != nil {
+		s.drainErr(err)
+	}
+	return s.Transform(op)
+}
+
+// MapWhen applies the function f only to items whose dynamic type matches the
+// type of the prototype value proto, other items continue downstream unchanged.
+// This makes type-specific handling in mixed-type streams declarative, for instance:
+//   strm.MapWhen(&Event{}, func(item interface{}) interface{} {...})
+// applies only to items of type *Event (not Event).
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#MapWhenFunc
+func (s *Stream) MapWhen(proto interface{}, f func(interface{}) interface{}) *Stream {
+	op, err := unary.MapWhenFunc(proto, f)
+	if err != nil {
+		s.drainErr(err)
+	}
+	return s.Transform(op)
+}
+
 /*
 func (s *Stream) MapWithConcurrency(f interface{}, concurrency int) *Stream {
 	op, err := unary.MapFunc(f)
@@ -92,3 +212,211 @@ func (s *Stream) FlatMap(f interface{}) *Stream {
 	s.ReStream()    // add streamop to unpack flatmap result
 	return s
 }
+
+// MapExpand expands each incoming item into n items by calling fn n times,
+// with indices 0..n-1, and sending each result downstream.  Nil results are
+// dropped, error results and panics in fn are reported to the error function.  It is
+// a clearer alternative to FlatMap when the fan-out factor is fixed and it
+// does not allocate an intermediate slice for each item.
+func (s *Stream) MapExpand(n int, fn func(item interface{}, i int) interface{}) *Stream {
+	if n <= 0 || fn == nil {
+		s.drainErr(errors.New("MapExpand requires a positive count and a function"))
+		return s
+	}
+	return s.appendOp(newExpander(n, fn))
+}
+
+// MapParallel applies the user-defined function f, as with Map, using n
+// concurrent workers while preserving the order of items downstream.  Items are
+// tagged with a sequence number on arrival and results are held in a reorder
+// buffer until all preceding results have been emitted.  At most 2n items can
+// be in flight or waiting in the reorder buffer at any time, which bounds memory
+// use.  If a worker stalls on an item, other workers keep processing until the
+// window is full, then the operator blocks (applying backpressure upstream)
+// until the stalled item completes.  Nil results are dropped and errors are
+// reported to the error function.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#MapFunc
+func (s *Stream) MapParallel(f interface{}, n int) *Stream {
+	if n <= 0 {
+		s.drainErr(errors.New("MapParallel requires a positive worker count"))
+		return s
+	}
+	op, err := unary.MapFunc(f)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	return s.appendOp(newOrderedMapper(n, op))
+}
+
+// MapParallelKeyed applies fn to incoming items using n concurrent workers.
+// Items are assigned to workers by the hash of the key returned by keyFn, so
+// all items with the same key are processed by the same worker in their order
+// of arrival while items with different keys are processed in parallel.  The
+// order of items is preserved per key, but not across keys.  This is suited for
+// stateful per-key transformations.  Nil results are dropped and StreamError
+// results are reported to the error function.  Keys are hashed with the seed
+// set with WithHashSeed.
+func (s *Stream) MapParallelKeyed(n int, keyFn func(interface{}) interface{}, fn func(interface{}) interface{}) *Stream {
+	if n <= 0 || keyFn == nil || fn == nil {
+		s.drainErr(errors.New("MapParallelKeyed requires a positive count, a key function, and a function"))
+		return s
+	}
+	return s.appendOp(newKeyedMapper(n, keyFn, fn))
+}
+
+// ParseJSON unmarshals incoming items of type []byte or string into new values
+// of the same type as the prototype value proto (i.e. a struct, a pointer to a struct,
+// or a map[string]interface{}).  Items that fail to unmarshal are reported to
+// the error function, with the raw item attached, and are not sent downstream.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#ParseJSONFunc
+func (s *Stream) ParseJSON(proto interface{}) *Stream {
+	op, err := unary.ParseJSONFunc(proto)
+	if err != nil {
+		s.drainErr(err)
+	}
+	return s.Transform(op)
+}
+
+// MapParseTime parses a timestamp field of incoming records into a time.Time
+// value using the provided layout (see package time).  The field is selected by
+// index: an int position for records of type []T, or a key for records of type
+// map[K]V.  A copy of the record, with the parsed field, is sent downstream as a
+// []interface{} or a map[K]interface{} respectively.  Values that fail to parse
+// are reported to the error function, with the raw value attached.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#ParseTimeFunc
+func (s *Stream) MapParseTime(index interface{}, layout string) *Stream {
+	op, err := unary.ParseTimeFunc(index, layout)
+	if err != nil {
+		s.drainErr(err)
+	}
+	return s.Transform(op)
+}
+
+// DropExpired drops items whose timestamp, returned by tsFn, is older than
+// ttl relative to the current time.  Expired items are reported to the error
+// function, with the item attached (use IntoSplit to route them to a side sink).
+// This is useful when consuming a backlog where stale events should not be
+// processed.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#DropExpiredFunc
+func (s *Stream) DropExpired(tsFn func(interface{}) time.Time, ttl time.Duration) *Stream {
+	op, err := unary.DropExpiredFunc(tsFn, ttl)
+	if err != nil {
+		s.drainErr(err)
+	}
+	return s.Transform(op)
+}
+
+// EncodeJSON marshals incoming items to JSON and emits them as []byte.
+// Items that fail to marshal are reported to the error function.
+// It is equivalent to Encode(codec.JSON()).
+func (s *Stream) EncodeJSON() *Stream {
+	return s.Encode(codec.JSON())
+}
+
+// Encode marshals incoming items with the specified codec and emits them
+// as []byte.  Use it to serialize items before a generic bytes sink, for
+// instance:
+//   strm.Encode(codec.PrettyJSON("  "))
+//   strm.Encode(codec.Gob())
+// Items that fail to marshal are reported to the error function.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#EncodeFunc
+func (s *Stream) Encode(c codec.Codec) *Stream {
+	op, err := unary.EncodeFunc(c)
+	if err != nil {
+		s.drainErr(err)
+	}
+	return s.Transform(op)
+}
+
+// DistinctUntilChangedField suppresses consecutive items whose struct field
+// (or string map key) name is unchanged from the previous item.  It only emits
+// transitions, which is useful when reading sorted data with repeated key values.
+// It uses O(1) memory. Items missing the named field are reported to the
+// error function.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#DistinctUntilChangedFieldFunc
+func (s *Stream) DistinctUntilChangedField(name string) *Stream {
+	operator := unary.New()
+	operator.SetOperation(unary.DistinctUntilChangedFieldFunc(name))
+	return s.appendOp(operator)
+}
+
+// Distinct suppresses the items whose key, returned by keyFn, has already
+// been seen.  Function keyFn must be of type func(T) K or
+// func(context.Context, T) K, where K is a comparable key.  When keyFn is nil,
+// the item itself is used as key.  Since all seen keys are retained, memory
+// grows with the number of distinct keys, use DistinctWithin to bound memory
+// on unbounded streams.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#DistinctFunc
+func (s *Stream) Distinct(keyFn interface{}) *Stream {
+	op, err := unary.DistinctFunc(keyFn)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	operator := unary.New()
+	operator.SetOperation(op)
+	return s.appendOp(operator)
+}
+
+// DistinctWithin suppresses an item if its key, returned by keyFn, appeared
+// within the last window items.  When keyFn is nil, the item itself is used
+// as key.  It sits between DistinctUntilChanged (a window of 1) and a full
+// Distinct, with memory bounded to O(window), which is practical for
+// suppressing near-duplicates in ordered feeds.
+//
+// See Also
+//
+//   "github.com/taiyang-li/automi/operators/unary"#DistinctWithinFunc
+func (s *Stream) DistinctWithin(window int, keyFn func(interface{}) interface{}) *Stream {
+	op, err := unary.DistinctWithinFunc(window, keyFn)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	operator := unary.New()
+	operator.SetOperation(op)
+	return s.appendOp(operator)
+}
+
+// TraceItems assigns a unique correlation ID to each item, by wrapping it in
+// an api.StreamItem with the ID stored in its MetaData at key api.TraceIDKey.
+// Items already traced keep their existing ID.  Downstream unary operators
+// unwrap traced items before applying their functions, with the ID available
+// from the context for logging:
+//
+//   strm.TraceItems().Map(func(ctx context.Context, item T) R {
+//       log.Println(autoctx.GetTraceID(ctx), "processing", item)
+//       ...
+//   })
+//
+// Results are wrapped back with the trace, which is carried across unary
+// operators (Map, Filter, Process, etc) and operators that forward items
+// untouched (i.e. Throttle).  Traced items are unwrapped before the other
+// operators (i.e. Batch and the binary operators), where the trace ends, and
+// before the sink, which therefore receives the item data.
+func (s *Stream) TraceItems() *Stream {
+	s.tracing = true
+	return s.Transform(unary.TraceFunc())
+}
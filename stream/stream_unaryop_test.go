@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
 	"github.com/taiyang-li/automi/collectors"
 	"github.com/taiyang-li/automi/emitters"
 )
@@ -295,6 +299,28 @@ func TestStream_UnaryOpertorsErrorHandling(t *testing.T) {
 			},
 			expectedErrs: 1,
 		},
+		{
+			name: "error with panicking function",
+			stream: func() *Stream {
+				src := emitters.Slice([]string{"hello", "boom", "world"})
+				snk := collectors.Slice()
+				strm := New(src)
+				strm.Process(func(s string) interface{} {
+					if s == "boom" {
+						panic("boom")
+					}
+					return s
+				}).Into(snk)
+				return strm
+			},
+			errHandler: func(counter *int) api.ErrorFunc {
+				return func(err api.StreamError) {
+					t.Log("received error")
+					*counter++
+				}
+			},
+			expectedErrs: 1,
+		},
 		// {
 		// 	name: "error with PanicStreamError type",
 		// 	stream: func() *Stream {
@@ -339,3 +365,731 @@ func TestStream_UnaryOpertorsErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestStream_WithConcurrency(t *testing.T) {
+	// each item waits until all 4 items are in flight, which
+	// only completes when they are processed concurrently
+	var barrier sync.WaitGroup
+	barrier.Add(4)
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WithConcurrency(4).
+		Map(func(i int) int {
+			barrier.Done()
+			barrier.Wait()
+			return i * 10
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long, items not processed concurrently")
+	}
+
+	sum := 0
+	for _, item := range snk.Get() {
+		sum += item.(int)
+	}
+	if len(snk.Get()) != 4 || sum != 100 {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_AdaptiveParallel(t *testing.T) {
+	data := make([]int, 200)
+	for i := range data {
+		data[i] = i
+	}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(data)).Map(func(i int) int {
+		time.Sleep(100 * time.Microsecond)
+		return i
+	}).AdaptiveParallel(1, 4).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+	if len(snk.Get()) != len(data) {
+		t.Fatal("unexpected result count:", len(snk.Get()))
+	}
+}
+
+func TestStream_MapExpand(t *testing.T) {
+	snk := collectors.Slice()
+	errs := 0
+	strm := New(emitters.Slice([]string{"ab", "cd"})).
+		WithErrorFunc(func(api.StreamError) {
+			errs++
+		}).
+		MapExpand(3, func(item interface{}, i int) interface{} {
+			str := item.(string)
+			if i >= len(str) {
+				return api.Error("index out of range")
+			}
+			return string(str[i])
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"a", "b", "c", "d"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if errs != 2 {
+		t.Fatal("expecting 2 errors, got", errs)
+	}
+}
+
+func TestStream_MapExpand_Errors(t *testing.T) {
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]int{1, 2})).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		MapExpand(3, func(item interface{}, i int) interface{} {
+			switch i {
+			case 1:
+				return errors.New("plain error")
+			case 2:
+				panic("boom")
+			}
+			return item
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{1, 2}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if len(errs) != 4 || errs[0].Cause() == nil || errs[1].Item() == nil {
+		t.Fatal("unexpected errors:", errs)
+	}
+}
+
+func TestStream_MapParallel(t *testing.T) {
+	data := make([]int, 100)
+	for i := range data {
+		data[i] = i
+	}
+
+	var errCount int64
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(data)).
+		WithErrorFunc(func(api.StreamError) {
+			atomic.AddInt64(&errCount, 1)
+		}).
+		MapParallel(func(i int) interface{} {
+			// first item stalls, later items complete out of order
+			if i == 0 {
+				time.Sleep(5 * time.Millisecond)
+			}
+			time.Sleep(time.Duration(i%7) * 50 * time.Microsecond)
+			if i == 50 {
+				return api.Error("bad item")
+			}
+			return i * 2
+		}, 4).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+
+	var expected []interface{}
+	for _, i := range data {
+		if i != 50 {
+			expected = append(expected, i*2)
+		}
+	}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result order:", snk.Get())
+	}
+	if atomic.LoadInt64(&errCount) != 1 {
+		t.Fatal("expecting 1 error, got", errCount)
+	}
+}
+
+func TestStream_MapParallel_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).MapParallel(func(i int) int { return i }, 0).
+		Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for invalid worker count")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_MapParallelKeyed(t *testing.T) {
+	type event struct {
+		Key string
+		Seq int
+	}
+	var events []event
+	for seq := 0; seq < 50; seq++ {
+		for _, key := range []string{"a", "b", "c", "d"} {
+			events = append(events, event{key, seq})
+		}
+	}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(events)).MapParallelKeyed(3,
+		func(item interface{}) interface{} {
+			return item.(event).Key
+		},
+		func(item interface{}) interface{} {
+			return item
+		},
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != len(events) {
+		t.Fatal("unexpected result count:", len(snk.Get()))
+	}
+	lastSeq := make(map[string]int)
+	for _, item := range snk.Get() {
+		e := item.(event)
+		if last, ok := lastSeq[e.Key]; ok && e.Seq != last+1 {
+			t.Fatalf("key %s out of order: %d after %d", e.Key, e.Seq, last)
+		}
+		lastSeq[e.Key] = e.Seq
+	}
+}
+
+func TestStream_MapParallel_Panic(t *testing.T) {
+	var m sync.Mutex
+	var panicked []interface{}
+	onErr := func(err api.StreamError) {
+		m.Lock()
+		defer m.Unlock()
+		if err.Item() != nil {
+			panicked = append(panicked, err.Item().Item)
+		}
+	}
+
+	ordered, keyed := collectors.Slice(), collectors.Slice()
+	strms := []*Stream{
+		New(emitters.Slice([]int{1, 2, 3})).WithErrorFunc(onErr).
+			TraceItems().
+			MapParallel(func(ctx context.Context, i int) int {
+				if autoctx.GetTraceID(ctx) == "" {
+					t.Error("traced item not unwrapped")
+				}
+				if i == 2 {
+					panic("bad item")
+				}
+				return i
+			}, 2).Into(ordered),
+		New(emitters.Slice([]int{4, 5, 6})).WithErrorFunc(onErr).
+			TraceItems().
+			MapParallelKeyed(2,
+				func(item interface{}) interface{} {
+					return item.(int) % 2
+				},
+				func(item interface{}) interface{} {
+					if item.(int) == 5 {
+						panic("bad item")
+					}
+					return item
+				},
+			).Into(keyed),
+	}
+
+	for _, strm := range strms {
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+	}
+
+	if !reflect.DeepEqual(ordered.Get(), []interface{}{1, 3}) {
+		t.Fatal("unexpected MapParallel result:", ordered.Get())
+	}
+	if len(keyed.Get()) != 2 {
+		t.Fatal("unexpected MapParallelKeyed result:", keyed.Get())
+	}
+	m.Lock()
+	defer m.Unlock()
+	if !reflect.DeepEqual(panicked, []interface{}{2, 5}) {
+		t.Fatal("expecting panics reported with items, got", panicked)
+	}
+}
+
+func TestStream_ParseJSON(t *testing.T) {
+	type event struct {
+		Name string `json:"name"`
+	}
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]string{`{"name":"a"}`, `{bad`, `{"name":"b"}`})).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		ParseJSON(event{}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 2 || snk.Get()[0].(event).Name != "a" {
+		t.Fatal("unexpected parsed items:", snk.Get())
+	}
+	if len(errs) != 1 || errs[0].Item().Item != `{bad` {
+		t.Fatal("expecting parse error with raw item:", errs)
+	}
+}
+
+func TestStream_MapParseTime(t *testing.T) {
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([][]string{
+		{"login", "2020-05-17"},
+		{"logout", "17/05/2020"},
+	})).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		MapParseTime(1, "2006-01-02").Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 1 {
+		t.Fatal("unexpected parsed items:", snk.Get())
+	}
+	stamp := snk.Get()[0].([]interface{})[1].(time.Time)
+	if !stamp.Equal(time.Date(2020, 5, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("unexpected parsed time:", stamp)
+	}
+	if len(errs) != 1 || errs[0].Item().Item != "17/05/2020" {
+		t.Fatal("expecting parse error with raw value:", errs)
+	}
+}
+
+func TestStream_DropExpired(t *testing.T) {
+	type event struct {
+		Name string
+		At   time.Time
+	}
+	now := time.Now()
+	snk := collectors.Slice()
+	var expired []interface{}
+	strm := New(emitters.Slice([]event{
+		{"a", now}, {"b", now.Add(-time.Hour)}, {"c", now.Add(-time.Second)},
+	})).
+		WithErrorFunc(func(err api.StreamError) {
+			expired = append(expired, err.Item().Item)
+		}).
+		DropExpired(func(item interface{}) time.Time {
+			return item.(event).At
+		}, time.Minute).
+		Map(func(e event) string {
+			return e.Name
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"a", "c"}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if len(expired) != 1 || expired[0].(event).Name != "b" {
+		t.Fatal("unexpected expired items:", expired)
+	}
+}
+
+func TestStream_EncodeJSON(t *testing.T) {
+	type event struct {
+		Name string `json:"name"`
+	}
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]event{{"a"}, {"b"}})).EncodeJSON().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 2 || string(snk.Get()[1].([]byte)) != `{"name":"b"}` {
+		t.Fatal("unexpected encoded items:", snk.Get())
+	}
+}
+
+func TestStream_DistinctUntilChangedField(t *testing.T) {
+	type trade struct {
+		Symbol string
+		Price  float64
+	}
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]trade{
+		{"AAA", 1.0}, {"AAA", 1.1}, {"BBB", 2.0}, {"BBB", 2.1}, {"AAA", 1.2},
+	})).DistinctUntilChangedField("Symbol").Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(snk.Get()) != 3 || snk.Get()[2].(trade).Price != 1.2 {
+		t.Fatal("unexpected transitions:", snk.Get())
+	}
+}
+
+func TestStream_Distinct(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"Go", "rust", "go", "C", "RUST", "c"})).
+		Distinct(strings.ToLower).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"Go", "rust", "C"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_DistinctWithin(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "a", "c", "d", "a"})).
+		DistinctWithin(2, nil).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"a", "b", "c", "d", "a"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_MapWhen(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]interface{}{1, "a", 2, "b"})).MapWhen(0, func(item interface{}) interface{} {
+		return item.(int) * 10
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{10, "a", 20, "b"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_MapResult(t *testing.T) {
+	prices := map[string]float64{"apple": 1.25, "pear": 0.75}
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"apple", "kiwi", "pear"})).MapResult(func(name string) (float64, bool) {
+		price, ok := prices[name]
+		return price, ok
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{1.25, 0.75}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_Compact(t *testing.T) {
+	var nilItem *api.StreamItem
+	items := []interface{}{"a", "", nilItem, "b", 0}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(items)).Compact().Into(snk)
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"a", "b"}) {
+		t.Fatal("unexpected compact result:", snk.Get())
+	}
+
+	snk = collectors.Slice()
+	strm = New(emitters.Slice(items)).CompactNil().Into(snk)
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"a", "", "b", 0}) {
+		t.Fatal("unexpected compact nil result:", snk.Get())
+	}
+}
+
+func TestStream_Timeout(t *testing.T) {
+	snk := collectors.Slice()
+	errs := 0
+	strm := New(emitters.Slice([]int{5, 200, 5})).
+		WithErrorFunc(func(api.StreamError) {
+			errs++
+		}).
+		Map(func(ctx context.Context, i int) int {
+			select {
+			case <-time.After(time.Duration(i) * time.Millisecond):
+			case <-ctx.Done():
+			}
+			return i
+		}).Timeout(50 * time.Millisecond).
+		Map(func(i int) int {
+			return i * 2
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{10, 10}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if errs != 1 {
+		t.Fatal("expecting 1 timeout error, got", errs)
+	}
+}
+
+func TestStream_TraceItems(t *testing.T) {
+	snk := collectors.Slice()
+	var m sync.Mutex
+	idsByItem := make(map[int]string)
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		TraceItems().
+		Map(func(ctx context.Context, i int) int {
+			m.Lock()
+			idsByItem[i] = autoctx.GetTraceID(ctx)
+			m.Unlock()
+			return i * 10
+		}).
+		Filter(func(ctx context.Context, i int) bool {
+			m.Lock()
+			defer m.Unlock()
+			if id := autoctx.GetTraceID(ctx); id == "" || id != idsByItem[i/10] {
+				t.Error("trace ID not propagated for", i)
+			}
+			return i > 10
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	// sink receives the item data
+	if !reflect.DeepEqual(snk.Get(), []interface{}{20, 30}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_TraceItems_Batch(t *testing.T) {
+	snk := collectors.Slice()
+	strm := FromSlice([]int{1, 2, 3}).TraceItems().Batch().Sum().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{6.0}) {
+		t.Fatal("traced items not unwrapped before batch:", snk.Get())
+	}
+}
+
+func TestStream_Tap(t *testing.T) {
+	var tapped []interface{}
+	errCount := 0
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c"})).
+		WithErrorFunc(func(api.StreamError) {
+			errCount++
+		}).
+		Tap(func(item interface{}) {
+			if item == "b" {
+				panic("tap failed")
+			}
+			tapped = append(tapped, item)
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{"a", "b", "c"}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	if !reflect.DeepEqual(tapped, []interface{}{"a", "c"}) {
+		t.Fatal("unexpected tapped items:", tapped)
+	}
+	if errCount != 1 {
+		t.Fatal("expecting tap panic reported, got", errCount)
+	}
+}
+
+// trackedSource emits increasing ints until cancelled, then closes done
+type trackedSource struct {
+	output chan interface{}
+	done   chan struct{}
+}
+
+func (s *trackedSource) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+func (s *trackedSource) Open(ctx context.Context) error {
+	go func() {
+		defer close(s.done)
+		defer close(s.output)
+		for i := 0; ; i++ {
+			select {
+			case s.output <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func TestStream_CancellationError(t *testing.T) {
+	src := &trackedSource{output: make(chan interface{}), done: make(chan struct{})}
+	errCount := 0
+	// the second worker keeps running unless the stream is cancelled
+	strm := New(src).
+		WithConcurrency(2).
+		WithErrorFunc(func(api.StreamError) {
+			errCount++
+		}).
+		Process(func(i int) interface{} {
+			if i == 10 {
+				return api.CancellationError("stop")
+			}
+			return i
+		}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	select {
+	case <-src.done:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("emitter still running after cancellation error")
+	}
+	if errCount != 1 {
+		t.Fatal("expecting cancellation error reported, got", errCount)
+	}
+}
@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"errors"
+	"time"
+
+	"github.com/taiyang-li/automi/operators/batch"
+	"github.com/taiyang-li/automi/operators/unary"
+	"github.com/taiyang-li/automi/operators/window"
+)
+
+// SlidingAggregate maintains a rolling aggregate over the last size items
+// and emits the current aggregate after each incoming item.  Function add
+// is applied to the entering item and function remove to the item exiting the
+// window, making moving aggregates (i.e. moving sums) O(1) per item instead
+// of re-folding the whole window.  The aggregate starts as nil, so add must
+// handle a nil accumulator.
+//
+// See Also
+//
+// See also the operator function SlidingAggregateFunc in
+//   "github.com/taiyang-li/automi/operators/window"
+func (s *Stream) SlidingAggregate(size int, add, remove func(acc, item interface{}) interface{}) *Stream {
+	operator := unary.New()
+	operator.SetOperation(window.SlidingAggregateFunc(size, add, remove))
+	return s.appendOp(operator)
+}
+
+// WindowCount emits, as []interface{}, the last size items of the stream every
+// slide items (count-based sliding window), which is suitable for moving
+// computations (i.e. moving average) downstream.  The first window is emitted
+// once size items are received.  When slide < size, windows overlap; when
+// slide > size, the items received between windows are dropped.  Remaining
+// items that do not complete a slide are not emitted.
+//
+// See Also
+//
+// See also the operator function CountFunc in
+//   "github.com/taiyang-li/automi/operators/window"
+func (s *Stream) WindowCount(size, slide int) *Stream {
+	op, err := window.CountFunc(size, slide)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	operator := unary.New()
+	operator.SetOperation(op)
+	return s.appendOp(operator)
+}
+
+// WindowReduce reduces the items received within each tumbling time window of
+// duration d and emits one accumulated value per window.  The accumulator is
+// reset to initial at each window boundary and items are folded with function
+// fn, of type func(S,T) R, as with Reduce.  Windows without items emit nothing
+// and the last partial window is flushed when the stream closes.  For instance,
+// to count the requests received every minute:
+//
+//   strm.WindowReduce(time.Minute, 0, func(count int, req Request) int {
+//       return count + 1
+//   })
+//
+// See Also
+//
+// See also the operator function ReduceFunc in
+//   "github.com/taiyang-li/automi/operators/window"
+func (s *Stream) WindowReduce(d time.Duration, initial interface{}, fn interface{}) *Stream {
+	if d <= 0 {
+		s.drainErr(errors.New("WindowReduce duration must be greater than zero"))
+		return s
+	}
+	op, err := window.ReduceFunc(initial, fn)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	s.BatchByDuration(d)
+	operator := unary.New()
+	operator.SetOperation(op)
+	return s.appendOp(operator)
+}
+
+// WindowedCountByKey groups incoming items in consecutive windows of size
+// items and emits, for each window, a map[interface{}]int with the count of
+// items by the key returned by keyFn (or the item itself when keyFn is nil).
+// The last window, with the remaining items, is flushed when the stream closes.
+// Items with a nil key are not counted, when no items of a window are counted
+// an empty map is emitted if emitEmpty is true, otherwise the window is skipped.
+//
+// See Also
+//
+// See also the operator function CountByKeyFunc in
+//   "github.com/taiyang-li/automi/operators/batch"
+func (s *Stream) WindowedCountByKey(size int, keyFn func(interface{}) interface{}, emitEmpty bool) *Stream {
+	if size <= 0 {
+		s.drainErr(errors.New("WindowedCountByKey size must be greater than zero"))
+		return s
+	}
+	s.BatchBySize(int64(size))
+	operator := unary.New()
+	operator.SetOperation(batch.CountByKeyFunc(keyFn, emitEmpty))
+	return s.appendOp(operator)
+}
+
+// BufferUntil buffers incoming items and sends the accumulated items
+// downstream, as a []interface{}, each time a signal is received on trigger
+// (empty buffers are not sent).  The remaining items are sent when the stream
+// closes, closing trigger only disables further signals.  This allows the release
+// of items on external events (i.e. a manual commit) that cannot be expressed
+// with time or count windows.
+//
+// The buffer is unbounded: items are held in memory until the next signal,
+// therefore the trigger must fire often enough for the rate of the stream.
+func (s *Stream) BufferUntil(trigger <-chan struct{}) *Stream {
+	if trigger == nil {
+		s.drainErr(errors.New("BufferUntil requires a trigger channel"))
+		return s
+	}
+	return s.appendOp(newSignalBuffer(trigger))
+}
@@ -0,0 +1,227 @@
+package stream
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_SlidingAggregate(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).SlidingAggregate(2,
+		func(acc, item interface{}) interface{} {
+			if acc == nil {
+				return item.(int)
+			}
+			return acc.(int) + item.(int)
+		},
+		func(acc, item interface{}) interface{} {
+			return acc.(int) - item.(int)
+		},
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{1, 3, 5, 7, 9}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected moving sums:", snk.Get())
+	}
+}
+
+func TestStream_WindowCount(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		WindowCount(3, 2).
+		Map(func(window []interface{}) float64 {
+			sum := 0
+			for _, item := range window {
+				sum += item.(int)
+			}
+			return float64(sum) / float64(len(window))
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{2.0, 4.0}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected moving averages:", snk.Get())
+	}
+}
+
+func TestStream_WindowCount_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).WindowCount(2, 0)
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for zero slide")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_WindowReduce(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.SliceWithInterval([]int{1, 2, 3, 4}, 10*time.Millisecond)).
+		WindowReduce(25*time.Millisecond, 0, func(sum, item int) int {
+			return sum + item
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	// window boundaries depend on timing, but each window emits the
+	// sum of its own items and together they cover the whole stream
+	result := snk.Get()
+	if len(result) < 2 {
+		t.Fatal("expecting several windows, got", result)
+	}
+	total := 0
+	for _, sum := range result {
+		total += sum.(int)
+	}
+	if total != 10 {
+		t.Fatal("unexpected window sums:", result)
+	}
+}
+
+func TestStream_WindowReduce_Flush(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WindowReduce(time.Hour, 10, func(sum, item int) int {
+			return sum + item
+		}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{20}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected partial window:", snk.Get())
+	}
+}
+
+func TestStream_WindowReduce_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).WindowReduce(0, 0, func(a, b int) int { return a + b })
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for zero duration")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_WindowedCountByKey(t *testing.T) {
+	snk := collectors.Slice()
+	words := []string{"go", "rust", "go", "go", "zig", "go", "rust"}
+	strm := New(emitters.Slice(words)).WindowedCountByKey(3, nil, false).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{
+		map[interface{}]int{"go": 2, "rust": 1},
+		map[interface{}]int{"go": 2, "zig": 1},
+		map[interface{}]int{"rust": 1},
+	}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_WindowedCountByKey_EmitEmpty(t *testing.T) {
+	keyFn := func(item interface{}) interface{} {
+		if i := item.(int); i > 0 {
+			return i
+		}
+		return nil
+	}
+
+	for _, emitEmpty := range []bool{false, true} {
+		snk := collectors.Slice()
+		strm := New(emitters.Slice([]int{1, 1, 0, 0})).WindowedCountByKey(2, keyFn, emitEmpty).Into(snk)
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		expected := 1
+		if emitEmpty {
+			expected = 2
+		}
+		if len(snk.Get()) != expected {
+			t.Fatalf("emitEmpty=%v: unexpected result: %v", emitEmpty, snk.Get())
+		}
+	}
+}
+
+func TestStream_BufferUntil(t *testing.T) {
+	src := make(chan int)
+	trigger := make(chan struct{})
+	snk := collectors.Slice()
+	strm := New(src).BufferUntil(trigger).Into(snk)
+	errCh := strm.Open()
+
+	src <- 1
+	src <- 2
+	time.Sleep(5 * time.Millisecond)
+	trigger <- struct{}{}
+	trigger <- struct{}{} // empty buffer, not flushed
+	src <- 3
+	close(src)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{[]interface{}{1, 2}, []interface{}{3}}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
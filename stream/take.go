@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// Take truncates the stream to its first n items.  Once n items are sent
+// downstream, the output of the operation is closed which completes the
+// downstream operations (i.e. a Batch placed after Take emits its batch).
+// The remaining upstream items are drained and discarded so that upstream
+// producers are not blocked.  If n <= 0, no item is emitted and the output
+// is closed immediately.  If n is larger than the number of streamed items,
+// all items are emitted.
+func (s *Stream) Take(n int) *Stream {
+	return s.appendOp(newTaker(n))
+}
+
+// taker is an operator that forwards the first n items
+// and discards the rest.
+type taker struct {
+	n      int
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newTaker creates a *taker
+func newTaker(n int) *taker {
+	return &taker{
+		n:      n,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (t *taker) SetInput(in <-chan interface{}) {
+	t.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (t *taker) GetOutput() <-chan interface{} {
+	return t.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (t *taker) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	t.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (t *taker) Exec(ctx context.Context) error {
+	t.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(t.logf, "Take operator starting")
+
+	if t.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		count := 0
+		closed := false
+		closeOutput := func() {
+			if !closed {
+				util.Logfn(t.logf, "Take operator closing")
+				close(t.output)
+				closed = true
+			}
+		}
+		defer closeOutput()
+
+		if t.n <= 0 {
+			closeOutput()
+		}
+
+		for {
+			select {
+			case item, opened := <-t.input:
+				if !opened {
+					return
+				}
+				if closed { // drain
+					continue
+				}
+				select {
+				case t.output <- item:
+				case <-ctx.Done():
+					return
+				}
+				count++
+				if count >= t.n {
+					closeOutput()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
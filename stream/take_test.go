@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_Take(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		expected []interface{}
+	}{
+		{"first items", 2, []interface{}{1, 2}},
+		{"zero items", 0, nil},
+		{"negative", -1, nil},
+		{"more than stream", 10, []interface{}{1, 2, 3, 4, 5}},
+	}
+
+	for _, test := range tests {
+		snk := collectors.Slice()
+		strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).Take(test.n).Into(snk)
+
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		if !reflect.DeepEqual(snk.Get(), test.expected) {
+			t.Fatalf("%s: unexpected result %v", test.name, snk.Get())
+		}
+	}
+}
+
+func TestStream_Take_Batch(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c", "d"})).Take(3).Batch().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{[]string{"a", "b", "c"}}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
+
+func TestStream_Take_UnboundedSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// producer never closes its channel
+	ch := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case ch <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	snk := collectors.Slice()
+	strm := New(ch).Take(3).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{0, 1, 2}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+}
@@ -0,0 +1,132 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// throttler is an operator that limits the rate of items sent downstream
+// using a token bucket.  The bucket holds up to rate tokens and is refilled
+// with one token every per/rate.  Items wait for a token, they are never dropped.
+type throttler struct {
+	rate     int
+	interval time.Duration
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+}
+
+// newThrottler creates a *throttler emitting at most rate items per period
+func newThrottler(rate int, per time.Duration) *throttler {
+	return &throttler{
+		rate:     rate,
+		interval: per / time.Duration(rate),
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (t *throttler) SetInput(in <-chan interface{}) {
+	t.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (t *throttler) GetOutput() <-chan interface{} {
+	return t.output
+}
+
+// SetBufferSize sets the buffer size of the output channel
+func (t *throttler) SetBufferSize(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	t.output = make(chan interface{}, bufferSize)
+}
+
+// Exec is the execution starting point for the executor node.
+func (t *throttler) Exec(ctx context.Context) error {
+	t.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(t.logf, "Throttle operator starting")
+
+	if t.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(t.logf, "Throttle operator closing")
+			close(t.output)
+		}()
+
+		tokens := t.rate
+		last := time.Now()
+		for {
+			select {
+			case item, opened := <-t.input:
+				if !opened {
+					return
+				}
+				if !t.take(ctx, &tokens, &last) {
+					return
+				}
+				select {
+				case t.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// take refills the bucket for the time elapsed since last refill, then
+// takes a token, waiting for one if the bucket is empty.  It returns
+// false if the context is done while waiting.
+func (t *throttler) take(ctx context.Context, tokens *int, last *time.Time) bool {
+	if refill := int(time.Since(*last) / t.interval); refill > 0 {
+		*tokens += refill
+		*last = last.Add(time.Duration(refill) * t.interval)
+		if *tokens >= t.rate {
+			*tokens = t.rate
+			*last = time.Now()
+		}
+	}
+	if *tokens == 0 {
+		timer := time.NewTimer(t.interval - time.Since(*last))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return false
+		}
+		*last = last.Add(t.interval)
+		return true
+	}
+	*tokens--
+	return true
+}
+
+// Throttle limits the rate of items sent downstream to at most rate items
+// per period per.  It uses a token bucket which allows bursts of up to rate
+// items, then emits one item every per/rate.  Items are never dropped: when
+// the rate is exceeded the operator blocks, applying backpressure to upstream
+// operators and the source.  It is typically used before an operator that
+// calls a rate-limited service, for instance:
+//
+//   strm.Throttle(10, time.Second).Map(callService)
+func (s *Stream) Throttle(rate int, per time.Duration) *Stream {
+	if rate <= 0 || per <= 0 || per/time.Duration(rate) <= 0 {
+		s.drainErr(errors.New("Throttle requires a positive rate and period"))
+		return s
+	}
+	return s.appendOp(newThrottler(rate, per))
+}
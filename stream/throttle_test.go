@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_Throttle(t *testing.T) {
+	var m sync.Mutex
+	var times []time.Time
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		Throttle(2, 20*time.Millisecond).
+		Map(func(i int) int {
+			m.Lock()
+			times = append(times, time.Now())
+			m.Unlock()
+			return i
+		}).Into(snk)
+
+	start := time.Now()
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !reflect.DeepEqual(snk.Get(), []interface{}{1, 2, 3, 4, 5, 6}) {
+		t.Fatal("unexpected result:", snk.Get())
+	}
+	// burst of 2 items, then 4 items at 10ms intervals
+	if elapsed := times[len(times)-1].Sub(start); elapsed < 35*time.Millisecond {
+		t.Fatal("items not throttled, took", elapsed)
+	}
+	if elapsed := times[1].Sub(start); elapsed > 8*time.Millisecond {
+		t.Fatal("expecting initial burst, took", elapsed)
+	}
+}
+
+func TestStream_Throttle_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	strm := New(emitters.Slice([]int{1, 2, 3})).WithContext(ctx).
+		Throttle(1, time.Hour).
+		Into(collectors.Null())
+
+	errs := strm.Open()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	select {
+	case <-errs:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("throttle did not exit on cancel")
+	}
+}
+
+func TestStream_Throttle_Invalid(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).Throttle(0, time.Second).Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for invalid rate")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
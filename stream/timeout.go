@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/util"
+)
+
+// WithTimeout sets an inactivity timeout for the stream: when no item is
+// received by the stream sink for duration d, the stream context is cancelled
+// and Open() returns a timeout error, even if the sink itself never returns
+// (i.e. a network sink blocked on a write).  The timeout keeps running after
+// the last item, until the sink completes.  This is an opt-in safety net for
+// jobs that must not hang; a zero or negative d disables the timeout.
+func (s *Stream) WithTimeout(d time.Duration) *Stream {
+	if d <= 0 {
+		s.timeout = nil
+		return s
+	}
+	s.timeout = newWatchdog(d)
+	return s
+}
+
+// watchdog is an operator placed before the stream sink that restarts a
+// timer each time an item is received by the sink.  When the timer expires,
+// the abort function is invoked with an error and channel expired is closed.
+type watchdog struct {
+	idle    time.Duration
+	abort   func(error)
+	expired chan struct{}
+	input   <-chan interface{}
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// newWatchdog creates a *watchdog with inactivity timeout d
+func newWatchdog(d time.Duration) *watchdog {
+	return &watchdog{
+		idle:    d,
+		expired: make(chan struct{}),
+		output:  make(chan interface{}), // unbuffered, sends complete when sink receives
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (w *watchdog) SetInput(in <-chan interface{}) {
+	w.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (w *watchdog) GetOutput() <-chan interface{} {
+	return w.output
+}
+
+// Exec is the execution starting point for the executor node.
+func (w *watchdog) Exec(ctx context.Context) error {
+	w.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(w.logf, "Timeout operator starting")
+
+	if w.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		timer := time.NewTimer(w.idle)
+		defer timer.Stop()
+
+		expire := func() {
+			util.Logfn(w.logf, "Timeout operator expired")
+			w.abort(fmt.Errorf("stream timed out after %s of inactivity", w.idle))
+			close(w.expired)
+		}
+		restart := func() {
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(w.idle)
+		}
+
+		input := w.input
+		for input != nil {
+			select {
+			case item, opened := <-input:
+				if !opened {
+					util.Logfn(w.logf, "Timeout operator closing")
+					close(w.output)
+					input = nil
+					continue
+				}
+				select {
+				case w.output <- item:
+					restart()
+				case <-timer.C:
+					expire()
+					return
+				case <-ctx.Done():
+					return
+				}
+			case <-timer.C:
+				expire()
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// input closed, wait for the sink to complete
+		select {
+		case <-timer.C:
+			expire()
+		case <-ctx.Done():
+		}
+	}()
+	return nil
+}
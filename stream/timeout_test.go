@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taiyang-li/automi/collectors"
+	"github.com/taiyang-li/automi/emitters"
+)
+
+func TestStream_WithTimeout(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c"})).
+		WithTimeout(50 * time.Millisecond).
+		Map(strings.ToUpper).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []interface{}{"A", "B", "C"}
+	if !reflect.DeepEqual(snk.Get(), expected) {
+		t.Fatal("unexpected collected items:", snk.Get())
+	}
+}
+
+func TestStream_WithTimeout_StuckSink(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+	snk := collectors.Func(func(item interface{}) error {
+		<-stuck // never returns during the test
+		return nil
+	})
+	strm := New(emitters.Slice([]string{"a", "b", "c"})).
+		WithTimeout(20 * time.Millisecond).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatal("expecting timeout error, got", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_WithTimeout_StalledSource(t *testing.T) {
+	src := make(chan string)
+	defer close(src)
+	strm := New(src).WithTimeout(20 * time.Millisecond).Into(collectors.Null())
+
+	go func() { src <- "a" }()
+
+	select {
+	case err := <-strm.Open():
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatal("expecting timeout error, got", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taiyang-li/automi/api"
+	autoctx "github.com/taiyang-li/automi/api/context"
+	"github.com/taiyang-li/automi/operators/unary"
+	"github.com/taiyang-li/automi/util"
+)
+
+// untracer is an operator that unwraps items traced with a
+// correlation ID or a source offset, so the next operator or the
+// sink receives the item data.  Other items are forwarded as is.
+type untracer struct {
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// newUntracer creates an *untracer
+func newUntracer() *untracer {
+	return &untracer{
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (u *untracer) SetInput(in <-chan interface{}) {
+	u.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (u *untracer) GetOutput() <-chan interface{} {
+	return u.output
+}
+
+// Exec is the execution starting point for the executor node.
+func (u *untracer) Exec(ctx context.Context) error {
+	u.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(u.logf, "Untrace operator starting")
+
+	if u.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(u.logf, "Untrace operator closing")
+			close(u.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-u.input:
+				if !opened {
+					return
+				}
+				_, item, _ = unary.Untrace(ctx, item)
+				select {
+				case u.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// tracesItems returns true if op applies its function to the data
+// of traced items and keeps their trace, or forwards items untouched
+func tracesItems(op api.Operator) bool {
+	switch op.(type) {
+	case *unary.UnaryOperator, *orderedMapper, *keyedMapper, *throttler, *delayer, *debouncer, *taker, *skipper, *untracer,
+		*offsetter, *checkpointer:
+		return true
+	}
+	return false
+}
+
+// setupTracing unwraps traced items ahead of the operators that do not
+// handle traces (i.e. batch and binary operators) and ahead of the sink,
+// unless the checkpoint operator, which unwraps items, precedes the sink
+func (s *Stream) setupTracing() {
+	if !s.tracing && s.checkpoint == nil {
+		return
+	}
+	ops := make([]api.Operator, 0, len(s.ops)+1)
+	for _, op := range s.ops {
+		if !tracesItems(op) {
+			ops = append(ops, newUntracer())
+		}
+		ops = append(ops, op)
+	}
+	if s.checkpoint == nil {
+		ops = append(ops, newUntracer())
+	}
+	s.ops = ops
+}
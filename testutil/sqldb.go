@@ -0,0 +1,183 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// SQLDB is an in-memory database/sql driver used to test SQL emitters and
+// collectors without a database.  Queries return Rows, with column names
+// Columns, followed by RowsErr if set.  Executed statements record their
+// arguments, which are kept when their transaction (if any) is committed.
+type SQLDB struct {
+	Columns []string
+	Rows    [][]driver.Value
+	RowsErr error
+	// ExecErr, if set, is called with the arguments of each executed
+	// statement and its error is returned by the statement
+	ExecErr func(args []driver.Value) error
+
+	mutex     sync.Mutex
+	executed  [][]driver.Value
+	commits   int
+	rollbacks int
+	closed    int
+}
+
+// Open returns a *sql.DB backed by the in-memory driver
+func (d *SQLDB) Open() *sql.DB {
+	return sql.OpenDB(sqlConnector{db: d})
+}
+
+// Executed returns the arguments of the executed, and committed, statements
+func (d *SQLDB) Executed() [][]driver.Value {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.executed
+}
+
+// Commits returns the number of committed transactions
+func (d *SQLDB) Commits() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.commits
+}
+
+// Rollbacks returns the number of rolled back transactions
+func (d *SQLDB) Rollbacks() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.rollbacks
+}
+
+// RowsClosed returns the number of query results that were closed
+func (d *SQLDB) RowsClosed() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.closed
+}
+
+type sqlConnector struct {
+	db *SQLDB
+}
+
+func (c sqlConnector) Connect(context.Context) (driver.Conn, error) {
+	return &sqlConn{db: c.db}, nil
+}
+
+func (c sqlConnector) Driver() driver.Driver {
+	return sqlDriver{}
+}
+
+type sqlDriver struct{}
+
+func (sqlDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("testutil: use SQLDB.Open")
+}
+
+type sqlConn struct {
+	db *SQLDB
+	tx *sqlTx
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return &sqlStmt{conn: c}, nil
+}
+
+func (c *sqlConn) Close() error {
+	return nil
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	c.tx = &sqlTx{conn: c}
+	return c.tx, nil
+}
+
+type sqlTx struct {
+	conn    *sqlConn
+	pending [][]driver.Value
+}
+
+func (t *sqlTx) Commit() error {
+	db := t.conn.db
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.executed = append(db.executed, t.pending...)
+	db.commits++
+	t.conn.tx = nil
+	return nil
+}
+
+func (t *sqlTx) Rollback() error {
+	db := t.conn.db
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.rollbacks++
+	t.conn.tx = nil
+	return nil
+}
+
+type sqlStmt struct {
+	conn *sqlConn
+}
+
+func (s *sqlStmt) Close() error {
+	return nil
+}
+
+func (s *sqlStmt) NumInput() int {
+	return -1
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	if db.ExecErr != nil {
+		if err := db.ExecErr(args); err != nil {
+			return nil, err
+		}
+	}
+	if s.conn.tx != nil {
+		s.conn.tx.pending = append(s.conn.tx.pending, args)
+		return driver.RowsAffected(1), nil
+	}
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.executed = append(db.executed, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &sqlRows{db: s.conn.db}, nil
+}
+
+type sqlRows struct {
+	db  *SQLDB
+	pos int
+}
+
+func (r *sqlRows) Columns() []string {
+	return r.db.Columns
+}
+
+func (r *sqlRows) Close() error {
+	r.db.mutex.Lock()
+	defer r.db.mutex.Unlock()
+	r.db.closed++
+	return nil
+}
+
+func (r *sqlRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.db.Rows) {
+		if r.db.RowsErr != nil {
+			return r.db.RowsErr
+		}
+		return io.EOF
+	}
+	copy(dest, r.db.Rows[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+// HashKey returns a 64-bit FNV-1a hash of the string representation
+// of key.  The hash is deterministic across runs for keys with
+// deterministic string representations.
+func HashKey(key interface{}) uint64 {
+	return HashKeySeed(key, 0)
+}
+
+// HashKeySeed returns a 64-bit FNV-1a hash of the string representation
+// of key, mixed with seed.  Different seeds yield different hash values
+// for the same key while a given seed is reproducible across runs.  A
+// seed of 0 yields the same value as HashKey.
+func HashKeySeed(key interface{}, seed uint64) uint64 {
+	h := fnv.New64a()
+	if seed != 0 {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], seed)
+		h.Write(buf[:])
+	}
+	fmt.Fprintf(h, "%T:%v", key, key)
+	return h.Sum64()
+}
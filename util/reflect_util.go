@@ -43,6 +43,17 @@ func ValueAsFloat(item reflect.Value) float64 {
 }
 
 func IsLess(itemI, itemJ reflect.Value) bool {
+	// compare the values held by interfaces (i.e. items of []interface{})
+	if itemI.IsValid() && itemI.Kind() == reflect.Interface {
+		itemI = itemI.Elem()
+	}
+	if itemJ.IsValid() && itemJ.Kind() == reflect.Interface {
+		itemJ = itemJ.Elem()
+	}
+	if !itemI.IsValid() || !itemJ.IsValid() {
+		return false
+	}
+
 	switch {
 	case IsIntValue(itemI) && IsIntValue(itemJ):
 		return itemI.Int() < itemJ.Int()
@@ -57,3 +68,15 @@ func IsLess(itemI, itemJ reflect.Value) bool {
 	}
 	return false
 }
+
+// IsSendChan returns true if ch is a channel that values can be sent to
+func IsSendChan(ch interface{}) bool {
+	chType := reflect.TypeOf(ch)
+	return chType != nil && chType.Kind() == reflect.Chan && chType.ChanDir()&reflect.SendDir != 0
+}
+
+// IsRecvChan returns true if ch is a channel that values can be received from
+func IsRecvChan(ch interface{}) bool {
+	chType := reflect.TypeOf(ch)
+	return chType != nil && chType.Kind() == reflect.Chan && chType.ChanDir()&reflect.RecvDir != 0
+}